@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// GetLowStockProducts возвращает продукты, остаток которых достиг точки заказа,
+// вместе с рекомендуемым количеством к заказу
+func GetLowStockProducts(w http.ResponseWriter, r *http.Request) {
+	rows, err := database.DB.Query(productSelect + " WHERE p.reorder_point > 0 AND p.quantity <= p.reorder_point ORDER BY p.name")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	var products []models.Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		products = append(products, p)
+	}
+	applyPriceVisibility(r, products)
+
+	items := []models.LowStockItem{}
+	for _, p := range products {
+		items = append(items, models.LowStockItem{Product: p, SuggestedQuantity: services.SuggestedOrderQuantity(p)})
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, items)
+}
+
+// GetStockAlerts возвращает оповещения об остатках (по умолчанию только открытые, ?status=all — все)
+func GetStockAlerts(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT a.id, a.product_id, p.name, a.alert_type, a.quantity, a.threshold, a.created_at, a.resolved_at
+		FROM stock_alerts a
+		JOIN products p ON a.product_id = p.id
+	`
+	if r.URL.Query().Get("status") != "all" {
+		query += " WHERE a.resolved_at IS NULL"
+	}
+	query += " ORDER BY a.created_at DESC, a.id DESC"
+
+	rows, err := database.DB.Query(query)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	alerts := []models.StockAlert{}
+	for rows.Next() {
+		var a models.StockAlert
+		err := rows.Scan(&a.ID, &a.ProductID, &a.ProductName, &a.AlertType, &a.Quantity, &a.Threshold, &a.CreatedAt, &a.ResolvedAt)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		alerts = append(alerts, a)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, alerts)
+}
+
+// UpdateProductLevels задаёт минимальный остаток, точку заказа и целевой остаток продукта
+func UpdateProductLevels(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var levels struct {
+		MinLevel     int `json:"min_level"`
+		ReorderPoint int `json:"reorder_point"`
+		TargetLevel  int `json:"target_level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&levels); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if msg := validateLevels(levels.MinLevel, levels.ReorderPoint, levels.TargetLevel); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE products SET min_level = ?, reorder_point = ?, target_level = ? WHERE id = ?
+	`, levels.MinLevel, levels.ReorderPoint, levels.TargetLevel, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	p, err := loadProduct(database.DB, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, p)
+}
+
+// validateLevels проверяет согласованность уровней запаса и возвращает текст ошибки
+func validateLevels(minLevel, reorderPoint, targetLevel int) string {
+	if minLevel < 0 || reorderPoint < 0 || targetLevel < 0 {
+		return "Stock levels must not be negative"
+	}
+	if reorderPoint > 0 && minLevel > reorderPoint {
+		return "Minimum level must not exceed reorder point"
+	}
+	if targetLevel > 0 && reorderPoint > targetLevel {
+		return "Reorder point must not exceed target level"
+	}
+	return ""
+}
@@ -94,3 +94,11 @@ func Register(w http.ResponseWriter, r *http.Request) {
 
 	utils.RespondWithJSON(w, http.StatusCreated, map[string]string{"message": "User created"})
 }
+
+// currentUsername возвращает имя пользователя из JWT-токена запроса
+func currentUsername(r *http.Request) string {
+	if claims, ok := middleware.GetClaims(r); ok {
+		return claims.Username
+	}
+	return ""
+}
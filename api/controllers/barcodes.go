@@ -0,0 +1,129 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// GetProductByBarcode ищет продукт по отсканированному штрихкоду. Для весовых EAN-13
+// (префикс 20–29) продукт определяется по префиксу, а из кода извлекается вес.
+func GetProductByBarcode(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+
+	var lookup models.BarcodeLookup
+	b := &lookup.Barcode
+	err := database.DB.QueryRow(`
+		SELECT id, product_id, code, barcode_type, weighted, package_id FROM product_barcodes WHERE code = ? AND weighted = 0
+	`, code).Scan(&b.ID, &b.ProductID, &b.Code, &b.BarcodeType, &b.Weighted, &b.PackageID)
+	if err == sql.ErrNoRows {
+		prefix, weight, ok := services.ParseWeightedBarcode(code)
+		if !ok {
+			utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+			return
+		}
+		err = database.DB.QueryRow(`
+			SELECT id, product_id, code, barcode_type, weighted FROM product_barcodes WHERE code = ? AND weighted = 1
+		`, prefix).Scan(&b.ID, &b.ProductID, &b.Code, &b.BarcodeType, &b.Weighted)
+		lookup.Quantity = &weight
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	p, err := loadProduct(database.DB, b.ProductID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	products := []models.Product{p}
+	applyPriceVisibility(r, products)
+	lookup.Product = products[0]
+
+	// Штрихкод упаковки: в ответе — упаковка и её содержимое в базовых единицах
+	if b.PackageID != nil {
+		for i := range lookup.Product.Packages {
+			if pkg := lookup.Product.Packages[i]; pkg.ID == *b.PackageID {
+				lookup.Package = &pkg
+				lookup.Quantity = &pkg.Quantity
+			}
+		}
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, lookup)
+}
+
+// AddProductBarcode добавляет продукту штрихкод
+func AddProductBarcode(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var b models.Barcode
+	if err := json.NewDecoder(r.Body).Decode(&b); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Штрихкоды упаковок задаются вместе с упаковкой
+	b.PackageID = nil
+
+	var exists int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM products WHERE id = ?", id).Scan(&exists); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if exists == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	if err := insertProductBarcode(database.DB, id, &b); err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, b)
+}
+
+// DeleteProductBarcode удаляет штрихкод продукта
+func DeleteProductBarcode(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	barcodeID, err := strconv.Atoi(vars["barcode_id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid barcode ID")
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM product_barcodes WHERE id = ? AND product_id = ?", barcodeID, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Barcode not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Barcode deleted successfully"})
+}
@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// loadCategories загружает все категории с количеством продуктов в каждой
+func loadCategories() ([]*models.Category, error) {
+	rows, err := database.DB.Query(`
+		SELECT c.id, c.name, c.parent_id, (SELECT COUNT(*) FROM products p WHERE p.category_id = c.id)
+		FROM categories c
+		ORDER BY c.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []*models.Category
+	for rows.Next() {
+		var c models.Category
+		if err := rows.Scan(&c.ID, &c.Name, &c.ParentID, &c.ProductCount); err != nil {
+			return nil, err
+		}
+		categories = append(categories, &c)
+	}
+	return categories, rows.Err()
+}
+
+// GetCategories возвращает плоский список категорий
+func GetCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := loadCategories()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	buildCategoryTree(categories)
+
+	list := []models.Category{}
+	for _, c := range categories {
+		item := *c
+		item.Children = nil
+		list = append(list, item)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, list)
+}
+
+// GetCategoryTree возвращает дерево категорий с количеством продуктов для построения навигации
+func GetCategoryTree(w http.ResponseWriter, r *http.Request) {
+	categories, err := loadCategories()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, buildCategoryTree(categories))
+}
+
+// buildCategoryTree связывает категории в дерево, подсчитывает продукты
+// в поддеревьях и возвращает корневые категории
+func buildCategoryTree(categories []*models.Category) []*models.Category {
+	byID := make(map[int]*models.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+
+	roots := []*models.Category{}
+	for _, c := range categories {
+		if c.ParentID != nil && byID[*c.ParentID] != nil {
+			parent := byID[*c.ParentID]
+			parent.Children = append(parent.Children, c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var count func(c *models.Category) int
+	count = func(c *models.Category) int {
+		c.TotalCount = c.ProductCount
+		for _, child := range c.Children {
+			c.TotalCount += count(child)
+		}
+		return c.TotalCount
+	}
+	for _, root := range roots {
+		count(root)
+	}
+	return roots
+}
+
+// CreateCategory создает категорию или подкатегорию
+func CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var c models.Category
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Category name is required")
+		return
+	}
+	if msg := validateCategoryParent(0, c.ParentID); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec("INSERT INTO categories (name, parent_id) VALUES (?, ?)", c.Name, c.ParentID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	c.ID = int(id)
+	c.ProductCount, c.TotalCount, c.Children = 0, 0, nil
+
+	utils.RespondWithJSON(w, http.StatusCreated, c)
+}
+
+// UpdateCategory переименовывает категорию или переносит её в другую ветку
+func UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	var c models.Category
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if c.Name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Category name is required")
+		return
+	}
+	if msg := validateCategoryParent(id, c.ParentID); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec("UPDATE categories SET name = ?, parent_id = ? WHERE id = ?", c.Name, c.ParentID, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	c.ID = id
+	c.Children = nil
+	utils.RespondWithJSON(w, http.StatusOK, c)
+}
+
+// DeleteCategory удаляет категорию без подкатегорий и продуктов
+func DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid category ID")
+		return
+	}
+
+	var used int
+	err = database.DB.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM categories WHERE parent_id = ?) + (SELECT COUNT(*) FROM products WHERE category_id = ?)
+	`, id, id).Scan(&used)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if used > 0 {
+		utils.RespondWithError(w, http.StatusConflict, "Category has subcategories or products")
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM categories WHERE id = ?", id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Category deleted successfully"})
+}
+
+// validateCategoryParent проверяет, что родительская категория существует и не создаёт цикла
+func validateCategoryParent(id int, parentID *int) string {
+	if parentID == nil {
+		return ""
+	}
+	for current := *parentID; ; {
+		if current == id {
+			return "Category cannot be nested in itself"
+		}
+		var next sql.NullInt64
+		err := database.DB.QueryRow("SELECT parent_id FROM categories WHERE id = ?", current).Scan(&next)
+		if err == sql.ErrNoRows {
+			return "Parent category not found"
+		}
+		if err != nil {
+			return err.Error()
+		}
+		if !next.Valid {
+			return ""
+		}
+		current = int(next.Int64)
+	}
+}
+
+// GetTags возвращает все теги с количеством отмеченных ими продуктов
+func GetTags(w http.ResponseWriter, r *http.Request) {
+	rows, err := database.DB.Query(`
+		SELECT t.id, t.name, COUNT(pt.product_id)
+		FROM tags t
+		LEFT JOIN product_tags pt ON pt.tag_id = t.id
+		GROUP BY t.id
+		ORDER BY t.name
+	`)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var t models.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.ProductCount); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		tags = append(tags, t)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, tags)
+}
@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// GetProductConversions возвращает пересчёт единиц других размерностей для продукта
+func GetProductConversions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT c.product_id, c.unit_id, u.name, u.abbreviation, c.factor, pu.dimension
+		FROM product_unit_conversions c
+		JOIN units u ON c.unit_id = u.id
+		JOIN products p ON c.product_id = p.id
+		JOIN units pu ON p.unit_id = pu.id
+		WHERE c.product_id = ?
+		ORDER BY u.name
+	`, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	conversions := []models.ProductUnitConversion{}
+	for rows.Next() {
+		var c models.ProductUnitConversion
+		var dimension string
+		if err := rows.Scan(&c.ProductID, &c.UnitID, &c.UnitName, &c.UnitAbbreviation, &c.Factor, &dimension); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		c.BaseUnit = models.BaseUnits[dimension]
+		conversions = append(conversions, c)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, conversions)
+}
+
+// SetProductConversion задаёт пересчёт единицы другой размерности для продукта:
+// quantity единиц unit_id равны equals единиц equals_unit_id (по умолчанию — единицы продукта).
+// Рецептуры, где продукт указан в этой единице, пересчитываются.
+func SetProductConversion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var input struct {
+		UnitID       int     `json:"unit_id"`
+		Quantity     float64 `json:"quantity"`
+		Equals       float64 `json:"equals"`
+		EqualsUnitID int     `json:"equals_unit_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.Quantity == 0 {
+		input.Quantity = 1
+	}
+	if input.Quantity < 0 || input.Equals <= 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Quantities must be positive")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var productUnitID int
+	var productDimension string
+	err = tx.QueryRow(`
+		SELECT p.unit_id, u.dimension FROM products p JOIN units u ON p.unit_id = u.id WHERE p.id = ?
+	`, id).Scan(&productUnitID, &productDimension)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if input.EqualsUnitID == 0 {
+		input.EqualsUnitID = productUnitID
+	}
+
+	c := models.ProductUnitConversion{ProductID: id, UnitID: input.UnitID, BaseUnit: models.BaseUnits[productDimension]}
+	var unitDimension string
+	err = tx.QueryRow("SELECT name, abbreviation, dimension FROM units WHERE id = ?", input.UnitID).
+		Scan(&c.UnitName, &c.UnitAbbreviation, &unitDimension)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusBadRequest, "Unit not found")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	// Единицы одной размерности с продуктом пересчитываются по их коэффициентам,
+	// переопределять их нельзя — иначе у продукта появятся две разные базовые единицы
+	if unitDimension == productDimension {
+		utils.RespondWithError(w, http.StatusBadRequest, "Unit has the same dimension as the product unit")
+		return
+	}
+
+	var equalsDimension string
+	var equalsFactor float64
+	err = tx.QueryRow("SELECT dimension, factor FROM units WHERE id = ?", input.EqualsUnitID).Scan(&equalsDimension, &equalsFactor)
+	if err == sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusBadRequest, "Unit not found")
+		return
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if equalsDimension != productDimension {
+		utils.RespondWithError(w, http.StatusBadRequest, "equals_unit_id must have the same dimension as the product unit")
+		return
+	}
+	c.Factor = input.Equals * equalsFactor / input.Quantity
+
+	_, err = tx.Exec(`
+		INSERT INTO product_unit_conversions (product_id, unit_id, factor) VALUES (?, ?, ?)
+		ON CONFLICT (product_id, unit_id) DO UPDATE SET factor = excluded.factor
+	`, id, c.UnitID, c.Factor)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	_, err = tx.Exec(`
+		UPDATE dish_ingredients SET base_quantity = quantity * ? WHERE product_id = ? AND unit_id = ?
+	`, c.Factor, id, c.UnitID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, c)
+}
+
+// DeleteProductConversion удаляет пересчёт единицы, если он не используется
+// в рецептурах и условиях поставки
+func DeleteProductConversion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	unitID, err := strconv.Atoi(vars["unit_id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid unit ID")
+		return
+	}
+
+	var used int
+	err = database.DB.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM dish_ingredients WHERE product_id = ?1 AND unit_id = ?2)
+			+ (SELECT COUNT(*) FROM supplier_products WHERE product_id = ?1 AND unit_id = ?2)
+	`, id, unitID).Scan(&used)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if used > 0 {
+		utils.RespondWithError(w, http.StatusConflict, "Conversion is used in recipes or supplier terms")
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM product_unit_conversions WHERE product_id = ? AND unit_id = ?", id, unitID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Conversion not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Conversion deleted successfully"})
+}
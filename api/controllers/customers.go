@@ -0,0 +1,264 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/mattn/go-sqlite3"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// respondWithCustomerError переводит ошибки сохранения гостя в HTTP-ответ
+func respondWithCustomerError(w http.ResponseWriter, err error) {
+	var sqliteErr sqlite3.Error
+	switch {
+	case errors.Is(err, services.ErrCustomerNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique:
+		utils.RespondWithError(w, http.StatusConflict, "Phone is already used by another customer")
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// customerInput — тело запроса на добавление и изменение гостя; баланс баллов
+// меняется только начислением и списанием
+type customerInput struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+	Email string `json:"email"`
+	Note  string `json:"note"`
+}
+
+// normalize обрезает пробелы и проверяет обязательные поля; возвращает текст ошибки или пустую строку
+func (in *customerInput) normalize() string {
+	in.Name = strings.TrimSpace(in.Name)
+	in.Phone = strings.TrimSpace(in.Phone)
+	in.Email = strings.TrimSpace(in.Email)
+	if in.Name == "" {
+		return "Customer name is required"
+	}
+	if in.Email != "" && !strings.Contains(in.Email, "@") {
+		return "Invalid email"
+	}
+	return ""
+}
+
+// GetCustomers возвращает гостей (?search= — по имени, телефону или email)
+func GetCustomers(w http.ResponseWriter, r *http.Request) {
+	query := "SELECT id, name, phone, email, note, points, created_at FROM customers"
+	var args []interface{}
+	if search := strings.TrimSpace(r.URL.Query().Get("search")); search != "" {
+		query += " WHERE name LIKE ?1 OR phone LIKE ?1 OR email LIKE ?1"
+		args = append(args, "%"+search+"%")
+	}
+	query += " ORDER BY name, id"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	customers := []models.Customer{}
+	for rows.Next() {
+		var c models.Customer
+		if err := rows.Scan(&c.ID, &c.Name, &c.Phone, &c.Email, &c.Note, &c.Points, &c.CreatedAt); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		customers = append(customers, c)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, customers)
+}
+
+// GetCustomer возвращает гостя с балансом баллов
+func GetCustomer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+
+	c, err := services.LoadCustomer(database.DB, id)
+	if err != nil {
+		respondWithCustomerError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, c)
+}
+
+// CreateCustomer добавляет постоянного гостя
+func CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	var input customerInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if msg := input.normalize(); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO customers (name, phone, email, note) VALUES (?, ?, ?, ?)
+	`, input.Name, input.Phone, input.Email, input.Note)
+	if err != nil {
+		respondWithCustomerError(w, err)
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	c, err := services.LoadCustomer(database.DB, int(id))
+	if err != nil {
+		respondWithCustomerError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, c)
+}
+
+// UpdateCustomer изменяет контакты гостя
+func UpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+
+	var input customerInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if msg := input.normalize(); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE customers SET name = ?, phone = ?, email = ?, note = ? WHERE id = ?
+	`, input.Name, input.Phone, input.Email, input.Note, id)
+	if err != nil {
+		respondWithCustomerError(w, err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		respondWithCustomerError(w, services.ErrCustomerNotFound)
+		return
+	}
+
+	c, err := services.LoadCustomer(database.DB, id)
+	if err != nil {
+		respondWithCustomerError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, c)
+}
+
+// DeleteCustomer удаляет гостя без заказов и движения баллов
+func DeleteCustomer(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+
+	var used int
+	err = database.DB.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM orders WHERE customer_id = ?1)
+			+ (SELECT COUNT(*) FROM loyalty_transactions WHERE customer_id = ?1)
+	`, id).Scan(&used)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if used > 0 {
+		utils.RespondWithError(w, http.StatusConflict, "Customer has orders or loyalty history")
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM customers WHERE id = ?", id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		respondWithCustomerError(w, services.ErrCustomerNotFound)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Customer deleted successfully"})
+}
+
+// GetCustomerHistory возвращает заказы гостя (с последних), сумму оплаченных заказов
+// и журнал начисления и списания баллов
+func GetCustomerHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+
+	c, err := services.LoadCustomer(database.DB, id)
+	if err != nil {
+		respondWithCustomerError(w, err)
+		return
+	}
+	history := models.CustomerHistory{Customer: c, Orders: []models.Order{}}
+
+	rows, err := database.DB.Query("SELECT id FROM orders WHERE customer_id = ? ORDER BY created_at DESC, id DESC", id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var orderIDs []int
+	for rows.Next() {
+		var orderID int
+		if err := rows.Scan(&orderID); err != nil {
+			rows.Close()
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		orderIDs = append(orderIDs, orderID)
+	}
+	rows.Close()
+
+	for _, orderID := range orderIDs {
+		o, err := loadOrder(database.DB, orderID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		o.History = nil
+		if o.Status == models.OrderPaid {
+			history.OrdersCount++
+			history.TotalSpent += o.AmountDue
+		}
+		history.Orders = append(history.Orders, o)
+	}
+	history.TotalSpent = utils.RoundMoney(history.TotalSpent)
+
+	history.Transactions, err = services.CustomerTransactions(database.DB, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, history)
+}
@@ -0,0 +1,334 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/mattn/go-sqlite3"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/middleware"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// applyDishCostVisibility скрывает себестоимость блюда от пользователей без роли admin или manager
+func applyDishCostVisibility(r *http.Request, d *models.Dish) {
+	if middleware.HasRole(r, "admin", "manager") {
+		return
+	}
+	d.CostPerPortion = nil
+	for i := range d.Ingredients {
+		d.Ingredients[i].PortionCost = nil
+	}
+}
+
+// respondWithDishError переводит ошибки сохранения блюда в HTTP-ответ
+func respondWithDishError(w http.ResponseWriter, err error) {
+	var sqliteErr sqlite3.Error
+	switch {
+	case errors.Is(err, services.ErrDishNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique:
+		utils.RespondWithError(w, http.StatusConflict, "Dish with this name already exists")
+	default:
+		respondWithStockError(w, err)
+	}
+}
+
+// validateDish проверяет поля блюда; возвращает текст ошибки или пустую строку
+func validateDish(d models.Dish) string {
+	if d.Name == "" {
+		return "Dish name is required"
+	}
+	if d.Yield < 1 {
+		return "Yield must be at least one portion"
+	}
+	if d.PortionSize < 0 {
+		return "Portion size must not be negative"
+	}
+	if len(d.Ingredients) == 0 {
+		return "Dish must have at least one ingredient"
+	}
+	return ""
+}
+
+// setDishIngredients заменяет рецептуру блюда. Количество ингредиента может быть
+// указано в любой единице, совместимой с единицей продукта (по умолчанию — в ней самой).
+func setDishIngredients(tx *sql.Tx, dishID int, ingredients []models.DishIngredient) error {
+	if _, err := tx.Exec("DELETE FROM dish_ingredients WHERE dish_id = ?", dishID); err != nil {
+		return err
+	}
+	for _, i := range ingredients {
+		if i.Quantity <= 0 {
+			return services.ErrInvalidQuantity
+		}
+		if i.UnitID == 0 {
+			err := tx.QueryRow("SELECT unit_id FROM products WHERE id = ?", i.ProductID).Scan(&i.UnitID)
+			if err == sql.ErrNoRows {
+				return services.ErrProductNotFound
+			}
+			if err != nil {
+				return err
+			}
+		}
+		base, err := services.ConvertToBase(tx, i.ProductID, i.UnitID, i.Quantity)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO dish_ingredients (dish_id, product_id, quantity, unit_id, base_quantity) VALUES (?, ?, ?, ?, ?)
+		`, dishID, i.ProductID, i.Quantity, i.UnitID, base)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetDishes возвращает список блюд с рецептурами
+func GetDishes(w http.ResponseWriter, r *http.Request) {
+	rows, err := database.DB.Query("SELECT id FROM dishes ORDER BY name")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	dishes := []models.Dish{}
+	for _, id := range ids {
+		d, err := services.LoadDish(database.DB, id)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		applyDishCostVisibility(r, &d)
+		dishes = append(dishes, d)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, dishes)
+}
+
+// GetDish возвращает блюдо с рецептурой и себестоимостью порции
+func GetDish(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid dish ID")
+		return
+	}
+
+	d, err := services.LoadDish(database.DB, id)
+	if err != nil {
+		respondWithDishError(w, err)
+		return
+	}
+	applyDishCostVisibility(r, &d)
+
+	utils.RespondWithJSON(w, http.StatusOK, d)
+}
+
+// CreateDish добавляет блюдо вместе с рецептурой
+func CreateDish(w http.ResponseWriter, r *http.Request) {
+	var d models.Dish
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if msg := validateDish(d); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO dishes (name, description, yield, portion_size, portion_unit_id) VALUES (?, ?, ?, ?, ?)
+	`, d.Name, d.Description, d.Yield, d.PortionSize, d.PortionUnitID)
+	if err != nil {
+		respondWithDishError(w, err)
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	if err := setDishIngredients(tx, int(id), d.Ingredients); err != nil {
+		respondWithDishError(w, err)
+		return
+	}
+
+	d, err = services.LoadDish(tx, int(id))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, d)
+}
+
+// UpdateDish обновляет блюдо и полностью заменяет его рецептуру
+func UpdateDish(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid dish ID")
+		return
+	}
+
+	var d models.Dish
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if msg := validateDish(d); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE dishes SET name = ?, description = ?, yield = ?, portion_size = ?, portion_unit_id = ? WHERE id = ?
+	`, d.Name, d.Description, d.Yield, d.PortionSize, d.PortionUnitID, id)
+	if err != nil {
+		respondWithDishError(w, err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Dish not found")
+		return
+	}
+
+	if err := setDishIngredients(tx, id, d.Ingredients); err != nil {
+		respondWithDishError(w, err)
+		return
+	}
+
+	d, err = services.LoadDish(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, d)
+}
+
+// DeleteDish удаляет блюдо вместе с рецептурой
+func DeleteDish(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid dish ID")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM dish_ingredients WHERE dish_id = ?", id); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result, err := tx.Exec("DELETE FROM dishes WHERE id = ?", id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Dish not found")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Dish deleted successfully"})
+}
+
+// GetDishCapacity возвращает, сколько порций блюда можно приготовить из текущего
+// свободного остатка, и ограничивающий ингредиент
+func GetDishCapacity(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid dish ID")
+		return
+	}
+
+	d, err := services.LoadDish(database.DB, id)
+	if err != nil {
+		respondWithDishError(w, err)
+		return
+	}
+
+	capacity, err := services.DishCapacity(database.DB, d)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, capacity)
+}
+
+// GetDishLabel возвращает этикетку блюда для меню: аллергены и пищевую ценность порции
+func GetDishLabel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid dish ID")
+		return
+	}
+
+	d, err := services.LoadDish(database.DB, id)
+	if err != nil {
+		respondWithDishError(w, err)
+		return
+	}
+
+	label, err := services.BuildDishLabel(database.DB, d)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, label)
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+const forecastSelect = `
+	SELECT f.product_id, p.name, u.dimension, f.available, f.lookback_days, f.average_daily_usage,
+		f.trend_daily_usage, f.trend_slope, f.days_of_supply, f.stockout_date, f.computed_at
+	FROM product_forecasts f
+	JOIN products p ON f.product_id = p.id
+	JOIN units u ON p.unit_id = u.id
+`
+
+func scanForecast(row rowScanner) (models.ConsumptionForecast, error) {
+	var f models.ConsumptionForecast
+	var dimension string
+	err := row.Scan(&f.ProductID, &f.ProductName, &dimension, &f.Available, &f.LookbackDays, &f.AverageDailyUsage,
+		&f.TrendDailyUsage, &f.TrendSlope, &f.DaysOfSupply, &f.StockoutDate, &f.ComputedAt)
+	f.BaseUnit = models.BaseUnits[dimension]
+	return f, err
+}
+
+// GetProductForecast возвращает последний рассчитанный прогноз расхода продукта
+func GetProductForecast(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	f, err := scanForecast(database.DB.QueryRow(forecastSelect+" WHERE f.product_id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Forecast not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, f)
+}
+
+// RecalculateForecasts пересчитывает прогнозы, не дожидаясь фоновой задачи
+// (?lookback_days= — период истории)
+func RecalculateForecasts(w http.ResponseWriter, r *http.Request) {
+	lookback, _, err := shoppingListParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := services.RecalculateForecasts(lookback); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Forecasts recalculated"})
+}
+
+// GetStockoutReport возвращает расходуемые продукты, отсортированные по дате исчерпания
+// остатка — первыми те, что закончатся раньше (?within_days= — только исчерпание в эти дни)
+func GetStockoutReport(w http.ResponseWriter, r *http.Request) {
+	query := forecastSelect + " WHERE f.average_daily_usage > 0"
+	var args []interface{}
+	if value := r.URL.Query().Get("within_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "within_days must not be negative")
+			return
+		}
+		query += " AND f.stockout_date IS NOT NULL AND f.stockout_date <= ?"
+		args = append(args, time.Now().UTC().AddDate(0, 0, days).Format("2006-01-02"))
+	}
+	query += " ORDER BY f.stockout_date IS NULL, f.stockout_date, f.days_of_supply, p.name"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	forecasts := []models.ConsumptionForecast{}
+	for rows.Next() {
+		f, err := scanForecast(rows)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		forecasts = append(forecasts, f)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, forecasts)
+}
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// GetKitchenQueue возвращает неготовые строки заказов, переданных на кухню, по участкам
+// (?station= — только один участок). Внутри участка старые заказы идут первыми.
+func GetKitchenQueue(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT oi.id, oi.order_id, COALESCE(t.name, ''), oi.name, oi.quantity, oi.station, oi.kitchen_status,
+			(SELECT MAX(h.changed_at) FROM order_status_history h WHERE h.order_id = o.id AND h.to_status = ?) AS sent_at,
+			oi.started_at, oi.started_by
+		FROM order_items oi
+		JOIN orders o ON oi.order_id = o.id
+		LEFT JOIN tables t ON o.table_id = t.id
+		WHERE o.status = ? AND oi.kitchen_status != ?
+	`
+	args := []interface{}{models.OrderSentToKitchen, models.OrderSentToKitchen, models.KitchenDone}
+	if station := r.URL.Query().Get("station"); station != "" {
+		query += " AND oi.station = ?"
+		args = append(args, station)
+	}
+	query += " ORDER BY oi.station, sent_at, oi.id"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	stations := []models.KitchenStation{}
+	for rows.Next() {
+		var ticket models.KitchenTicket
+		var station string
+		err := rows.Scan(&ticket.OrderItemID, &ticket.OrderID, &ticket.TableName, &ticket.Name, &ticket.Quantity,
+			&station, &ticket.Status, &ticket.SentAt, &ticket.StartedAt, &ticket.StartedBy)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if sentAt, err := utils.ParseDateTime(ticket.SentAt); err == nil {
+			ticket.SentAt = sentAt.Format(time.RFC3339)
+			ticket.AgeSeconds = int(now.Sub(sentAt).Seconds())
+		}
+
+		if len(stations) == 0 || stations[len(stations)-1].Station != station {
+			stations = append(stations, models.KitchenStation{Station: station})
+		}
+		current := &stations[len(stations)-1]
+		current.Items = append(current.Items, ticket)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, stations)
+}
+
+// StartKitchenItem отмечает, что строка заказа начала готовиться
+func StartKitchenItem(w http.ResponseWriter, r *http.Request) {
+	changeKitchenStatus(w, r, []string{models.KitchenPending}, models.KitchenInProgress,
+		"UPDATE order_items SET kitchen_status = ?1, started_at = CURRENT_TIMESTAMP, started_by = ?2 WHERE id = ?3",
+		models.KitchenItemStarted)
+}
+
+// CompleteKitchenItem отмечает строку заказа готовой (её можно завершить и без отметки о начале)
+func CompleteKitchenItem(w http.ResponseWriter, r *http.Request) {
+	changeKitchenStatus(w, r, []string{models.KitchenPending, models.KitchenInProgress}, models.KitchenDone, `
+		UPDATE order_items SET kitchen_status = ?1,
+			started_at = COALESCE(started_at, CURRENT_TIMESTAMP), started_by = COALESCE(started_by, ?2),
+			completed_at = CURRENT_TIMESTAMP, completed_by = ?2
+		WHERE id = ?3
+	`, models.KitchenItemCompleted)
+}
+
+// changeKitchenStatus переводит строку заказа между состояниями кухни; update получает
+// новое состояние (?1), имя пользователя (?2) и идентификатор строки (?3)
+func changeKitchenStatus(w http.ResponseWriter, r *http.Request, from []string, to string, update string, eventType string) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid order item ID")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var orderID int
+	var orderStatus, status, station string
+	err = tx.QueryRow(`
+		SELECT oi.order_id, o.status, oi.kitchen_status, oi.station
+		FROM order_items oi
+		JOIN orders o ON oi.order_id = o.id
+		WHERE oi.id = ?
+	`, id).Scan(&orderID, &orderStatus, &status, &station)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Order item not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if orderStatus != models.OrderSentToKitchen {
+		utils.RespondWithError(w, http.StatusConflict, "Order is not in the kitchen")
+		return
+	}
+	allowed := false
+	for _, s := range from {
+		if s == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		utils.RespondWithError(w, http.StatusConflict, "Cannot change kitchen status from "+status+" to "+to)
+		return
+	}
+
+	if _, err := tx.Exec(update, to, currentUsername(r), id); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	o, err := loadOrder(tx, orderID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	services.PublishKitchen(models.KitchenEvent{Type: eventType, OrderID: orderID, OrderItemID: id, Station: station})
+	utils.RespondWithJSON(w, http.StatusOK, o)
+}
+
+// KitchenStream отправляет события кухни в формате Server-Sent Events
+// (?station= — только события одного участка)
+func KitchenStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.RespondWithError(w, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+	station := r.URL.Query().Get("station")
+
+	events, unsubscribe := services.SubscribeKitchen()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	// Комментарии раз в 30 секунд не дают прокси закрыть соединение
+	heartbeat := time.NewTicker(30 * time.Second)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case e := <-events:
+			// События без участка (уход заказа с кухни) нужны всем экранам
+			if station != "" && e.Station != "" && e.Station != station {
+				continue
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// GetLocations возвращает список всех мест хранения с полными путями
+func GetLocations(w http.ResponseWriter, r *http.Request) {
+	paths, err := services.LocationPaths(database.DB)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	rows, err := database.DB.Query("SELECT id, name, parent_id, description FROM locations")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	locations := []models.Location{}
+	for rows.Next() {
+		var l models.Location
+		if err := rows.Scan(&l.ID, &l.Name, &l.ParentID, &l.Description); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		l.Path = paths[l.ID]
+		locations = append(locations, l)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, locations)
+}
+
+// GetLocation возвращает место хранения вместе с остатками продуктов в нём
+func GetLocation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid location ID")
+		return
+	}
+
+	var l models.Location
+	err = database.DB.QueryRow("SELECT id, name, parent_id, description FROM locations WHERE id = ?", id).
+		Scan(&l.ID, &l.Name, &l.ParentID, &l.Description)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Location not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	paths, err := services.LocationPaths(database.DB)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	l.Path = paths[l.ID]
+
+	rows, err := database.DB.Query(`
+		SELECT ls.location_id, ls.product_id, p.name, ls.quantity
+		FROM location_stock ls
+		JOIN products p ON ls.product_id = p.id
+		WHERE ls.location_id = ? AND ls.quantity <> 0
+		ORDER BY p.name
+	`, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ls models.LocationStock
+		if err := rows.Scan(&ls.LocationID, &ls.ProductID, &ls.ProductName, &ls.Quantity); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		ls.LocationPath = l.Path
+		l.Stock = append(l.Stock, ls)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, l)
+}
+
+// CreateLocation создает место хранения или ячейку внутри другого места
+func CreateLocation(w http.ResponseWriter, r *http.Request) {
+	var l models.Location
+	if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if l.Name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Location name is required")
+		return
+	}
+	if msg := validateLocationParent(0, l.ParentID); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec("INSERT INTO locations (name, parent_id, description) VALUES (?, ?, ?)",
+		l.Name, l.ParentID, l.Description)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	l.ID = int(id)
+	l.Stock = nil
+
+	paths, err := services.LocationPaths(database.DB)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	l.Path = paths[l.ID]
+
+	utils.RespondWithJSON(w, http.StatusCreated, l)
+}
+
+// UpdateLocation обновляет название, описание и родителя места хранения
+func UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid location ID")
+		return
+	}
+
+	var l models.Location
+	if err := json.NewDecoder(r.Body).Decode(&l); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if l.Name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Location name is required")
+		return
+	}
+	if msg := validateLocationParent(id, l.ParentID); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec("UPDATE locations SET name = ?, parent_id = ?, description = ? WHERE id = ?",
+		l.Name, l.ParentID, l.Description, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Location not found")
+		return
+	}
+
+	l.ID = id
+	l.Stock = nil
+	paths, err := services.LocationPaths(database.DB)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	l.Path = paths[l.ID]
+
+	utils.RespondWithJSON(w, http.StatusOK, l)
+}
+
+// DeleteLocation удаляет пустое место хранения без вложенных ячеек
+func DeleteLocation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid location ID")
+		return
+	}
+
+	var children, stocked int
+	err = database.DB.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM locations WHERE parent_id = ?),
+			(SELECT COUNT(*) FROM location_stock WHERE location_id = ? AND quantity <> 0)
+	`, id, id).Scan(&children, &stocked)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if children > 0 || stocked > 0 {
+		utils.RespondWithError(w, http.StatusConflict, "Location has sub-locations or stock")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM location_stock WHERE location_id = ?", id); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result, err := tx.Exec("DELETE FROM locations WHERE id = ?", id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Location not found")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Location deleted successfully"})
+}
+
+// validateLocationParent проверяет, что родитель существует и не создаёт цикла
+func validateLocationParent(id int, parentID *int) string {
+	if parentID == nil {
+		return ""
+	}
+	for current := *parentID; ; {
+		if current == id {
+			return "Location cannot be nested in itself"
+		}
+		var next sql.NullInt64
+		err := database.DB.QueryRow("SELECT parent_id FROM locations WHERE id = ?", current).Scan(&next)
+		if err == sql.ErrNoRows {
+			return "Parent location not found"
+		}
+		if err != nil {
+			return err.Error()
+		}
+		if !next.Valid {
+			return ""
+		}
+		current = int(next.Int64)
+	}
+}
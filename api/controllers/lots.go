@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// isValidDate проверяет, что дата указана в формате YYYY-MM-DD
+func isValidDate(value string) bool {
+	_, err := time.Parse("2006-01-02", value)
+	return err == nil
+}
+
+const lotSelect = `
+	SELECT l.id, l.product_id, p.name, l.lot_number, l.received_at, l.expiry_date,
+		l.quantity, l.blocked, l.blocked_reason
+	FROM lots l
+	JOIN products p ON l.product_id = p.id
+`
+
+func scanLot(row rowScanner) (models.Lot, error) {
+	var l models.Lot
+	err := row.Scan(&l.ID, &l.ProductID, &l.ProductName, &l.LotNumber, &l.ReceivedAt, &l.ExpiryDate,
+		&l.Quantity, &l.Blocked, &l.BlockedReason)
+	if err == nil && l.ExpiryDate != nil {
+		if expiry, err := time.Parse("2006-01-02", *l.ExpiryDate); err == nil {
+			today, _ := time.Parse("2006-01-02", time.Now().Format("2006-01-02"))
+			days := int(expiry.Sub(today).Hours() / 24)
+			l.DaysToExpiry = &days
+		}
+	}
+	return l, err
+}
+
+// GetLots возвращает партии с ненулевым остатком (?product_id= — по одному продукту, ?all=true — включая пустые)
+func GetLots(w http.ResponseWriter, r *http.Request) {
+	query := lotSelect + " WHERE 1 = 1"
+	var args []interface{}
+	if r.URL.Query().Get("all") != "true" {
+		query += " AND l.quantity > 0"
+	}
+	if value := r.URL.Query().Get("product_id"); value != "" {
+		productID, err := strconv.Atoi(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+			return
+		}
+		query += " AND l.product_id = ?"
+		args = append(args, productID)
+	}
+	query += " ORDER BY l.expiry_date IS NULL, l.expiry_date, l.id"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	lots := []models.Lot{}
+	for rows.Next() {
+		l, err := scanLot(rows)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		lots = append(lots, l)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, lots)
+}
+
+// GetExpiringLots возвращает партии с остатком, срок годности которых истекает
+// в ближайшие N дней (?days=, по умолчанию 7), включая уже просроченные
+func GetExpiringLots(w http.ResponseWriter, r *http.Request) {
+	days := 7
+	if value := r.URL.Query().Get("days"); value != "" {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 0 {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid number of days")
+			return
+		}
+	}
+
+	limit := time.Now().AddDate(0, 0, days).Format("2006-01-02")
+	rows, err := database.DB.Query(lotSelect+`
+		WHERE l.quantity > 0 AND l.expiry_date IS NOT NULL AND l.expiry_date <= ?
+		ORDER BY l.expiry_date, l.id
+	`, limit)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	lots := []models.Lot{}
+	for rows.Next() {
+		l, err := scanLot(rows)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		lots = append(lots, l)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, lots)
+}
@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// respondWithLoyaltyRuleError переводит ошибки правил начисления баллов в HTTP-ответ
+func respondWithLoyaltyRuleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrLoyaltyRuleNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// validateLoyaltyRule проверяет правило начисления баллов; возвращает текст ошибки или пустую строку
+func validateLoyaltyRule(q services.Querier, rule *models.LoyaltyRule) (string, error) {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return "Rule name is required", nil
+	}
+	if rule.SpendAmount <= 0 || rule.Points <= 0 {
+		return services.ErrInvalidLoyaltyRule.Error(), nil
+	}
+	if rule.MinOrderTotal < 0 {
+		return "min_order_total must not be negative", nil
+	}
+	if rule.MenuItemID != nil {
+		var exists int
+		if err := q.QueryRow("SELECT COUNT(*) FROM menu WHERE id = ?", *rule.MenuItemID).Scan(&exists); err != nil {
+			return "", err
+		}
+		if exists == 0 {
+			return "Menu item not found", nil
+		}
+	}
+	return "", nil
+}
+
+// loadLoyaltyRule загружает правило начисления баллов
+func loadLoyaltyRule(q services.Querier, id int) (models.LoyaltyRule, error) {
+	var rule models.LoyaltyRule
+	err := q.QueryRow(`
+		SELECT id, name, spend_amount, points, min_order_total, menu_item_id, active FROM loyalty_rules WHERE id = ?
+	`, id).Scan(&rule.ID, &rule.Name, &rule.SpendAmount, &rule.Points, &rule.MinOrderTotal, &rule.MenuItemID, &rule.Active)
+	if err == sql.ErrNoRows {
+		return rule, services.ErrLoyaltyRuleNotFound
+	}
+	return rule, err
+}
+
+// GetLoyaltyRules возвращает правила начисления баллов
+func GetLoyaltyRules(w http.ResponseWriter, r *http.Request) {
+	rows, err := database.DB.Query(`
+		SELECT id, name, spend_amount, points, min_order_total, menu_item_id, active FROM loyalty_rules ORDER BY id
+	`)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	rules := []models.LoyaltyRule{}
+	for rows.Next() {
+		var rule models.LoyaltyRule
+		err := rows.Scan(&rule.ID, &rule.Name, &rule.SpendAmount, &rule.Points, &rule.MinOrderTotal, &rule.MenuItemID, &rule.Active)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		rules = append(rules, rule)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, rules)
+}
+
+// CreateLoyaltyRule добавляет правило начисления баллов
+func CreateLoyaltyRule(w http.ResponseWriter, r *http.Request) {
+	rule := models.LoyaltyRule{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	msg, err := validateLoyaltyRule(database.DB, &rule)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO loyalty_rules (name, spend_amount, points, min_order_total, menu_item_id, active) VALUES (?, ?, ?, ?, ?, ?)
+	`, rule.Name, rule.SpendAmount, rule.Points, rule.MinOrderTotal, rule.MenuItemID, rule.Active)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id, _ := result.LastInsertId()
+	rule.ID = int(id)
+
+	utils.RespondWithJSON(w, http.StatusCreated, rule)
+}
+
+// UpdateLoyaltyRule изменяет правило начисления баллов; действует на заказы, оплаченные после изменения
+func UpdateLoyaltyRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid loyalty rule ID")
+		return
+	}
+
+	rule := models.LoyaltyRule{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	msg, err := validateLoyaltyRule(database.DB, &rule)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE loyalty_rules SET name = ?, spend_amount = ?, points = ?, min_order_total = ?, menu_item_id = ?, active = ?
+		WHERE id = ?
+	`, rule.Name, rule.SpendAmount, rule.Points, rule.MinOrderTotal, rule.MenuItemID, rule.Active, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		respondWithLoyaltyRuleError(w, services.ErrLoyaltyRuleNotFound)
+		return
+	}
+
+	rule, err = loadLoyaltyRule(database.DB, id)
+	if err != nil {
+		respondWithLoyaltyRuleError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, rule)
+}
+
+// DeleteLoyaltyRule удаляет правило начисления баллов; начисленные ранее баллы сохраняются
+func DeleteLoyaltyRule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid loyalty rule ID")
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM loyalty_rules WHERE id = ?", id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		respondWithLoyaltyRuleError(w, services.ErrLoyaltyRuleNotFound)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Loyalty rule deleted successfully"})
+}
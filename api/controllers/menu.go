@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+const menuSelect = `SELECT id, name, dish_id, product_id, price, currency, station, active, availability_override FROM menu`
+
+func scanMenuItem(row rowScanner) (models.MenuItem, error) {
+	var m models.MenuItem
+	err := row.Scan(&m.ID, &m.Name, &m.DishID, &m.ProductID, &m.Price, &m.Currency, &m.Station, &m.Active,
+		&m.AvailabilityOverride)
+	return m, err
+}
+
+// loadMenuItem загружает позицию меню и рассчитывает её доступность по остаткам
+func loadMenuItem(q services.Querier, id int) (models.MenuItem, error) {
+	item, err := scanMenuItem(q.QueryRow(menuSelect+" WHERE id = ?", id))
+	if err != nil {
+		return item, err
+	}
+	if err := services.MenuAvailability(q, &item); err != nil {
+		return item, err
+	}
+	err = services.MenuAllergens(q, &item)
+	return item, err
+}
+
+// menuItemInput — тело запроса на добавление и изменение позиции меню.
+// Без названия берётся название блюда или продукта, без цены для продукта — его цена продажи.
+type menuItemInput struct {
+	Name      string   `json:"name"`
+	DishID    *int     `json:"dish_id"`
+	ProductID *int     `json:"product_id"`
+	Price     *float64 `json:"price"`
+	Currency  string   `json:"currency"`
+	Station   string   `json:"station"`
+	Active    *bool    `json:"active"`
+}
+
+// buildMenuItem проверяет позицию меню и подставляет значения по умолчанию;
+// возвращает текст ошибки или пустую строку
+func buildMenuItem(q services.Querier, input menuItemInput) (models.MenuItem, string, error) {
+	item := models.MenuItem{
+		Name:      input.Name,
+		DishID:    input.DishID,
+		ProductID: input.ProductID,
+		Currency:  input.Currency,
+		Station:   input.Station,
+		Active:    input.Active == nil || *input.Active,
+	}
+	if (item.DishID == nil) == (item.ProductID == nil) {
+		return item, "Exactly one of dish_id and product_id is required", nil
+	}
+
+	var name string
+	var salePrice float64
+	var err error
+	if item.DishID != nil {
+		err = q.QueryRow("SELECT name FROM dishes WHERE id = ?", *item.DishID).Scan(&name)
+	} else {
+		err = q.QueryRow("SELECT name, sale_price FROM products WHERE id = ?", *item.ProductID).Scan(&name, &salePrice)
+	}
+	if err == sql.ErrNoRows {
+		return item, "Dish or product not found", nil
+	}
+	if err != nil {
+		return item, "", err
+	}
+
+	if item.Name == "" {
+		item.Name = name
+	}
+	if item.Currency == "" {
+		item.Currency = models.DefaultCurrency
+	}
+	if item.Station == "" {
+		item.Station = models.DefaultStation
+	}
+	switch {
+	case input.Price != nil:
+		item.Price = *input.Price
+	case item.ProductID != nil:
+		item.Price = salePrice
+	default:
+		return item, "Price is required for dishes", nil
+	}
+	if err := services.ValidatePrice(0, item.Price, item.Currency); err != nil {
+		return item, err.Error(), nil
+	}
+	return item, "", nil
+}
+
+// GetMenu возвращает меню с доступным по остаткам числом порций
+// (?active=true — только позиции в продаже, ?available=true — только доступные сейчас)
+func GetMenu(w http.ResponseWriter, r *http.Request) {
+	query := menuSelect
+	if r.URL.Query().Get("active") == "true" {
+		query += " WHERE active = 1"
+	}
+	query += " ORDER BY name, id"
+
+	rows, err := database.DB.Query(query)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var items []models.MenuItem
+	for rows.Next() {
+		item, err := scanMenuItem(rows)
+		if err != nil {
+			rows.Close()
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		items = append(items, item)
+	}
+	rows.Close()
+
+	onlyAvailable := r.URL.Query().Get("available") == "true"
+	menu := []models.MenuItem{}
+	for _, item := range items {
+		if err := services.MenuAvailability(database.DB, &item); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if err := services.MenuAllergens(database.DB, &item); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if onlyAvailable && !item.Available {
+			continue
+		}
+		menu = append(menu, item)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, menu)
+}
+
+// CreateMenuItem добавляет позицию в меню
+func CreateMenuItem(w http.ResponseWriter, r *http.Request) {
+	var input menuItemInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	item, msg, err := buildMenuItem(database.DB, input)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO menu (name, dish_id, product_id, price, currency, station, active) VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, item.Name, item.DishID, item.ProductID, item.Price, item.Currency, item.Station, item.Active)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	item, err = loadMenuItem(database.DB, int(id))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, item)
+}
+
+// UpdateMenuItem изменяет позицию меню; цены в уже созданных заказах не меняются
+func UpdateMenuItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid menu item ID")
+		return
+	}
+
+	var input menuItemInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	item, msg, err := buildMenuItem(database.DB, input)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE menu SET name = ?, dish_id = ?, product_id = ?, price = ?, currency = ?, station = ?, active = ?
+		WHERE id = ?
+	`, item.Name, item.DishID, item.ProductID, item.Price, item.Currency, item.Station, item.Active, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Menu item not found")
+		return
+	}
+
+	item, err = loadMenuItem(database.DB, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, item)
+}
+
+// SetMenuAvailability задаёт ручную доступность позиции меню: "available" или
+// "unavailable" — независимо от остатков, "auto" — по остаткам
+func SetMenuAvailability(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid menu item ID")
+		return
+	}
+
+	var input struct {
+		Availability string `json:"availability"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var override *string
+	switch input.Availability {
+	case models.AvailabilityAvailable, models.AvailabilityUnavailable:
+		override = &input.Availability
+	case "auto":
+	default:
+		utils.RespondWithError(w, http.StatusBadRequest, "Availability must be available, unavailable or auto")
+		return
+	}
+
+	result, err := database.DB.Exec("UPDATE menu SET availability_override = ? WHERE id = ?", override, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Menu item not found")
+		return
+	}
+
+	item, err := loadMenuItem(database.DB, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	utils.RespondWithJSON(w, http.StatusOK, item)
+}
+
+// DeleteMenuItem удаляет позицию меню; позиция, которая уже есть в заказах, снимается с продажи
+func DeleteMenuItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid menu item ID")
+		return
+	}
+
+	var orders int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM order_items WHERE menu_item_id = ?", id).Scan(&orders); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	query := "DELETE FROM menu WHERE id = ?"
+	message := "Menu item deleted successfully"
+	if orders > 0 {
+		query = "UPDATE menu SET active = 0 WHERE id = ?"
+		message = "Menu item is used in orders and was deactivated"
+	}
+
+	result, err := database.DB.Exec(query, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Menu item not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": message})
+}
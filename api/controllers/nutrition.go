@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// SetProductNutrition задаёт аллергены и пищевую ценность продукта на 100 г (мл).
+// Не переданные поля остаются прежними; пустой список allergens снимает все аллергены.
+func SetProductNutrition(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var input struct {
+		Allergens []string          `json:"allergens"`
+		Nutrition *models.Nutrition `json:"nutrition"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM products WHERE id = ?", id).Scan(&exists); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if exists == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	if input.Allergens != nil {
+		if err := setProductAllergens(tx, id, input.Allergens); err != nil {
+			respondWithProductError(w, err)
+			return
+		}
+	}
+	if input.Nutrition != nil {
+		if err := setProductNutrition(tx, id, input.Nutrition); err != nil {
+			respondWithProductError(w, err)
+			return
+		}
+	}
+
+	p, err := loadProduct(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	products := []models.Product{p}
+	applyPriceVisibility(r, products)
+	utils.RespondWithJSON(w, http.StatusOK, products[0])
+}
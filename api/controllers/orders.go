@@ -0,0 +1,680 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// orderInput — тело запроса на создание и изменение заказа
+type orderInput struct {
+	TableID    *int   `json:"table_id"`
+	CustomerID *int   `json:"customer_id"`
+	Note       string `json:"note"`
+	Items      []struct {
+		MenuItemID int `json:"menu_item_id"`
+		Quantity   int `json:"quantity"`
+	} `json:"items"`
+}
+
+func loadOrder(q services.Querier, id int) (models.Order, error) {
+	var o models.Order
+	var tableName, customerName sql.NullString
+	err := q.QueryRow(`
+		SELECT o.id, o.table_id, t.name, o.status, o.note, o.created_by, o.created_at,
+			o.customer_id, c.name, o.points_redeemed, o.points_discount, o.points_earned
+		FROM orders o
+		LEFT JOIN tables t ON o.table_id = t.id
+		LEFT JOIN customers c ON o.customer_id = c.id
+		WHERE o.id = ?
+	`, id).Scan(&o.ID, &o.TableID, &tableName, &o.Status, &o.Note, &o.CreatedBy, &o.CreatedAt,
+		&o.CustomerID, &customerName, &o.PointsRedeemed, &o.PointsDiscount, &o.PointsEarned)
+	if err != nil {
+		return o, err
+	}
+	o.TableName = tableName.String
+	o.CustomerName = customerName.String
+
+	rows, err := q.Query(`
+		SELECT id, menu_item_id, name, quantity, price, station, kitchen_status, started_at, completed_at
+		FROM order_items WHERE order_id = ? ORDER BY id
+	`, id)
+	if err != nil {
+		return o, err
+	}
+	o.Items = []models.OrderItem{}
+	for rows.Next() {
+		var item models.OrderItem
+		err := rows.Scan(&item.ID, &item.MenuItemID, &item.Name, &item.Quantity, &item.Price,
+			&item.Station, &item.KitchenStatus, &item.StartedAt, &item.CompletedAt)
+		if err != nil {
+			rows.Close()
+			return o, err
+		}
+		item.Total = utils.RoundMoney(item.Price * float64(item.Quantity))
+		o.Total += item.Total
+		o.Items = append(o.Items, item)
+	}
+	rows.Close()
+	o.Total = utils.RoundMoney(o.Total)
+	o.AmountDue = utils.RoundMoney(o.Total - o.PointsDiscount)
+
+	rows, err = q.Query(`
+		SELECT from_status, to_status, changed_by, changed_at
+		FROM order_status_history
+		WHERE order_id = ?
+		ORDER BY changed_at, id
+	`, id)
+	if err != nil {
+		return o, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c models.OrderStatusChange
+		if err := rows.Scan(&c.FromStatus, &c.ToStatus, &c.ChangedBy, &c.ChangedAt); err != nil {
+			return o, err
+		}
+		o.History = append(o.History, c)
+	}
+
+	return o, rows.Err()
+}
+
+// recordOrderStatus записывает переход заказа в журнал состояний
+func recordOrderStatus(tx *sql.Tx, orderID int, from, to, username string) error {
+	_, err := tx.Exec(`
+		INSERT INTO order_status_history (order_id, from_status, to_status, changed_by) VALUES (?, ?, ?, ?)
+	`, orderID, from, to, username)
+	return err
+}
+
+// occupyTable проверяет, что за столик можно посадить гостей заказа orderID (0 — нового
+// заказа), и занимает его; возвращает текст ошибки или пустую строку
+func occupyTable(tx *sql.Tx, tableID, orderID int) (string, error) {
+	var status string
+	err := tx.QueryRow("SELECT status FROM tables WHERE id = ?", tableID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "Table not found", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if status == models.TableOutOfService {
+		return "Table is out of service", nil
+	}
+	var occupied int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM orders WHERE table_id = ? AND id != ? AND status IN (?, ?, ?)
+	`, tableID, orderID, models.OrderOpen, models.OrderSentToKitchen, models.OrderServed).Scan(&occupied)
+	if err != nil {
+		return "", err
+	}
+	if occupied > 0 {
+		return "Table is occupied by another order", nil
+	}
+	_, err = tx.Exec("UPDATE tables SET status = ? WHERE id = ?", models.TableOccupied, tableID)
+	return "", err
+}
+
+// releaseTable освобождает занятый столик, если у него не осталось незакрытых заказов
+func releaseTable(tx *sql.Tx, tableID *int) error {
+	if tableID == nil {
+		return nil
+	}
+	_, err := tx.Exec(`
+		UPDATE tables SET status = ?
+		WHERE id = ? AND status = ? AND NOT EXISTS (
+			SELECT 1 FROM orders WHERE table_id = ? AND status IN (?, ?, ?)
+		)
+	`, models.TableFree, *tableID, models.TableOccupied, *tableID,
+		models.OrderOpen, models.OrderSentToKitchen, models.OrderServed)
+	return err
+}
+
+// validateOrderCustomer проверяет, что гость заказа существует; возвращает текст ошибки или пустую строку
+func validateOrderCustomer(q services.Querier, customerID *int) (string, error) {
+	if customerID == nil {
+		return "", nil
+	}
+	var exists int
+	if err := q.QueryRow("SELECT COUNT(*) FROM customers WHERE id = ?", *customerID).Scan(&exists); err != nil {
+		return "", err
+	}
+	if exists == 0 {
+		return "Customer not found", nil
+	}
+	return "", nil
+}
+
+// setOrderItems заменяет строки заказа, фиксируя названия и цены позиций меню, и
+// резервирует под них продукты; возвращает текст ошибки или пустую строку
+func setOrderItems(tx *sql.Tx, orderID int, input orderInput, username string) (string, error) {
+	if len(input.Items) == 0 {
+		return "Order must have at least one item", nil
+	}
+	if _, err := tx.Exec("DELETE FROM order_items WHERE order_id = ?", orderID); err != nil {
+		return "", err
+	}
+	// Прежние резервы заказа снимаются, чтобы доступность позиций учитывала и их
+	if _, err := services.ReleaseReservations(tx, services.OrderReferenceType, orderID); err != nil {
+		return "", err
+	}
+
+	// Одна позиция может встречаться в заказе несколько раз — сверяем с порциями сумму
+	requested := make(map[int]int)
+	for _, in := range input.Items {
+		if in.Quantity <= 0 {
+			return "Quantity must be positive", nil
+		}
+		item, err := loadMenuItem(tx, in.MenuItemID)
+		if err == sql.ErrNoRows {
+			return "Menu item " + strconv.Itoa(in.MenuItemID) + " not found", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if !item.Available {
+			return "Menu item " + item.Name + " is not available", nil
+		}
+		requested[item.ID] += in.Quantity
+		forced := item.AvailabilityOverride != nil && *item.AvailabilityOverride == models.AvailabilityAvailable
+		if !forced && requested[item.ID] > item.AvailablePortions {
+			return "Only " + strconv.Itoa(item.AvailablePortions) + " portions of " + item.Name + " are available", nil
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO order_items (order_id, menu_item_id, name, quantity, price, station, kitchen_status)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, orderID, item.ID, item.Name, in.Quantity, item.Price, item.Station, models.KitchenPending)
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", services.ReserveOrderStock(tx, orderID, username)
+}
+
+// GetOrders возвращает список заказов (?status=, ?table_id= и ?customer_id= — фильтры)
+func GetOrders(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT o.id, o.table_id, COALESCE(t.name, ''), o.status, o.note, o.created_by, o.created_at,
+			COALESCE((SELECT SUM(oi.quantity * oi.price) FROM order_items oi WHERE oi.order_id = o.id), 0),
+			o.customer_id, COALESCE(c.name, ''), o.points_redeemed, o.points_discount, o.points_earned
+		FROM orders o
+		LEFT JOIN tables t ON o.table_id = t.id
+		LEFT JOIN customers c ON o.customer_id = c.id
+	`
+	var conditions []string
+	var args []interface{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		conditions = append(conditions, "o.status = ?")
+		args = append(args, status)
+	}
+	if value := r.URL.Query().Get("table_id"); value != "" {
+		tableID, err := strconv.Atoi(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid table ID")
+			return
+		}
+		conditions = append(conditions, "o.table_id = ?")
+		args = append(args, tableID)
+	}
+	if value := r.URL.Query().Get("customer_id"); value != "" {
+		customerID, err := strconv.Atoi(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid customer ID")
+			return
+		}
+		conditions = append(conditions, "o.customer_id = ?")
+		args = append(args, customerID)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY o.created_at DESC, o.id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	orders := []models.Order{}
+	for rows.Next() {
+		var o models.Order
+		err := rows.Scan(&o.ID, &o.TableID, &o.TableName, &o.Status, &o.Note, &o.CreatedBy, &o.CreatedAt, &o.Total,
+			&o.CustomerID, &o.CustomerName, &o.PointsRedeemed, &o.PointsDiscount, &o.PointsEarned)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		o.Total = utils.RoundMoney(o.Total)
+		o.AmountDue = utils.RoundMoney(o.Total - o.PointsDiscount)
+		orders = append(orders, o)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, orders)
+}
+
+// GetOrder возвращает заказ со строками
+func GetOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	o, err := loadOrder(database.DB, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Order not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, o)
+}
+
+// CreateOrder создаёт открытый заказ (за столиком или без него) и резервирует под него
+// продукты; остатки списываются только при передаче заказа на кухню
+func CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var input orderInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if input.TableID != nil {
+		msg, err := occupyTable(tx, *input.TableID, 0)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if msg != "" {
+			utils.RespondWithError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+
+	msg, err := validateOrderCustomer(tx, input.CustomerID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	username := currentUsername(r)
+	result, err := tx.Exec("INSERT INTO orders (table_id, customer_id, status, note, created_by) VALUES (?, ?, ?, ?, ?)",
+		input.TableID, input.CustomerID, models.OrderOpen, input.Note, username)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	if err := recordOrderStatus(tx, int(id), "", models.OrderOpen, username); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	msg, err = setOrderItems(tx, int(id), input, username)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	o, err := loadOrder(tx, int(id))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, o)
+}
+
+// UpdateOrder заменяет примечание и строки открытого заказа
+func UpdateOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var input orderInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	var tableID, customerID *int
+	var redeemed int
+	err = tx.QueryRow("SELECT status, table_id, customer_id, points_redeemed FROM orders WHERE id = ?", id).
+		Scan(&status, &tableID, &customerID, &redeemed)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Order not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if status != models.OrderOpen {
+		utils.RespondWithError(w, http.StatusConflict, "Only open orders can be changed")
+		return
+	}
+
+	// Списанные баллы принадлежат гостю заказа, поэтому сменить гостя можно только после снятия скидки
+	if redeemed > 0 && (input.CustomerID == nil || *input.CustomerID != *customerID) {
+		respondWithOrderError(w, services.ErrCustomerHasRedeemed)
+		return
+	}
+	msg, err := validateOrderCustomer(tx, input.CustomerID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Пересадка за другой столик
+	if input.TableID != nil {
+		msg, err := occupyTable(tx, *input.TableID, id)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if msg != "" {
+			utils.RespondWithError(w, http.StatusBadRequest, msg)
+			return
+		}
+	}
+	_, err = tx.Exec("UPDATE orders SET table_id = ?, customer_id = ?, note = ? WHERE id = ?",
+		input.TableID, input.CustomerID, input.Note, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := releaseTable(tx, tableID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	msg, err = setOrderItems(tx, id, input, currentUsername(r))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	o, err := loadOrder(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if o.PointsDiscount > o.Total {
+		respondWithOrderError(w, services.ErrPointsExceedTotal)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, o)
+}
+
+// respondWithOrderError переводит ошибки исполнения заказа в HTTP-ответ;
+// при нехватке продуктов перечисляет недостающие ингредиенты
+func respondWithOrderError(w http.ResponseWriter, err error) {
+	var missing *services.MissingIngredientsError
+	if errors.As(err, &missing) {
+		utils.RespondWithJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":   err.Error(),
+			"missing": missing.Items,
+		})
+		return
+	}
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound), errors.Is(err, services.ErrCustomerNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrInsufficientPoints), errors.Is(err, services.ErrOrderNotRedeemable),
+		errors.Is(err, services.ErrPointsExceedTotal), errors.Is(err, services.ErrCustomerHasRedeemed):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrOrderHasNoCustomer), errors.Is(err, services.ErrNegativePointsAmount):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithStockError(w, err)
+	}
+}
+
+// SendOrderToKitchen передаёт заказ на кухню и списывает ингредиенты одной транзакцией.
+// Если чего-то не хватает, заказ остаётся открытым, а в ответе перечислены недостающие продукты.
+func SendOrderToKitchen(w http.ResponseWriter, r *http.Request) {
+	changeOrderStatus(w, r, models.OrderSentToKitchen)
+}
+
+// ServeOrder отмечает, что заказ подан гостю
+func ServeOrder(w http.ResponseWriter, r *http.Request) {
+	changeOrderStatus(w, r, models.OrderServed)
+}
+
+// PayOrder закрывает поданный заказ оплатой; гостю заказа начисляются бонусные баллы
+func PayOrder(w http.ResponseWriter, r *http.Request) {
+	changeOrderStatus(w, r, models.OrderPaid)
+}
+
+// CancelOrder отменяет заказ: снимает его резервы, возвращает гостю списанные баллы,
+// а если заказ ещё на кухне — возвращает списанные ингредиенты на склад
+func CancelOrder(w http.ResponseWriter, r *http.Request) {
+	changeOrderStatus(w, r, models.OrderCancelled)
+}
+
+// RedeemOrderPoints списывает бонусные баллы гостя в счёт скидки на неоплаченный заказ.
+// Повторный запрос заменяет скидку, points = 0 снимает её.
+func RedeemOrderPoints(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var input struct {
+		Points int `json:"points"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if err := services.RedeemPoints(tx, id, input.Points, currentUsername(r)); err != nil {
+		respondWithOrderError(w, err)
+		return
+	}
+
+	o, err := loadOrder(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, o)
+}
+
+// changeOrderStatus переводит заказ в новое состояние по models.OrderTransitions.
+// Переход записывается в журнал с именем пользователя из токена.
+func changeOrderStatus(w http.ResponseWriter, r *http.Request, to string) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	var tableID *int
+	err = tx.QueryRow("SELECT status, table_id FROM orders WHERE id = ?", id).Scan(&status, &tableID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Order not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	allowed := false
+	for _, s := range models.OrderTransitions[status] {
+		if s == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		utils.RespondWithError(w, http.StatusConflict, "Cannot change order status from "+status+" to "+to)
+		return
+	}
+
+	username := currentUsername(r)
+	switch to {
+	case models.OrderSentToKitchen:
+		if err := services.DeductOrderStock(tx, id, username); err != nil {
+			respondWithOrderError(w, err)
+			return
+		}
+	case models.OrderCancelled:
+		if _, err := services.ReleaseReservations(tx, services.OrderReferenceType, id); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		// Поданные блюда уже съедены: на склад возвращаются только ингредиенты заказа,
+		// который ещё на кухне
+		if status == models.OrderSentToKitchen {
+			if err := services.RestoreOrderStock(tx, id, username); err != nil {
+				respondWithStockError(w, err)
+				return
+			}
+		}
+		if err := services.RefundRedeemedPoints(tx, id, username); err != nil {
+			respondWithOrderError(w, err)
+			return
+		}
+	case models.OrderPaid:
+		if err := services.AwardOrderPoints(tx, id, username); err != nil {
+			respondWithOrderError(w, err)
+			return
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE orders SET status = ? WHERE id = ?", to, id); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := recordOrderStatus(tx, id, status, to, username); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if to == models.OrderPaid || to == models.OrderCancelled {
+		if err := releaseTable(tx, tableID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	o, err := loadOrder(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	publishOrderKitchenEvents(o, status)
+	utils.RespondWithJSON(w, http.StatusOK, o)
+}
+
+// publishOrderKitchenEvents оповещает экраны кухни о заказе, который пришёл на кухню или ушёл с неё
+func publishOrderKitchenEvents(o models.Order, from string) {
+	switch {
+	case o.Status == models.OrderSentToKitchen:
+		var events []models.KitchenEvent
+		for _, item := range o.Items {
+			events = append(events, models.KitchenEvent{
+				Type:        models.KitchenItemAdded,
+				OrderID:     o.ID,
+				OrderItemID: item.ID,
+				Station:     item.Station,
+			})
+		}
+		services.PublishKitchen(events...)
+	case from == models.OrderSentToKitchen:
+		services.PublishKitchen(models.KitchenEvent{Type: models.KitchenOrderRemoved, OrderID: o.ID})
+	}
+}
@@ -0,0 +1,159 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// GetProductPackages возвращает уровни упаковки продукта со штрихкодами
+func GetProductPackages(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	p, err := loadProduct(database.DB, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	packages := p.Packages
+	if packages == nil {
+		packages = []models.ProductPackage{}
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, packages)
+}
+
+// AddProductPackage добавляет продукту уровень упаковки: name, содержимое quantity
+// в единице unit_id (по умолчанию — единица продукта) и необязательный штрихкод
+func AddProductPackage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var input struct {
+		Name     string  `json:"name"`
+		Quantity float64 `json:"quantity"`
+		UnitID   int     `json:"unit_id"`
+		Barcode  string  `json:"barcode"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Package name is required")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	pkg := models.ProductPackage{ProductID: id, Name: input.Name}
+	pkg.Quantity, err = inputToBase(tx, id, input.UnitID, input.Quantity)
+	if err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	var duplicates int
+	err = tx.QueryRow("SELECT COUNT(*) FROM product_packages WHERE product_id = ? AND name = ?", id, pkg.Name).Scan(&duplicates)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if duplicates > 0 {
+		utils.RespondWithError(w, http.StatusConflict, "Product already has a package with this name")
+		return
+	}
+
+	result, err := tx.Exec("INSERT INTO product_packages (product_id, name, quantity) VALUES (?, ?, ?)", id, pkg.Name, pkg.Quantity)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	packageID, _ := result.LastInsertId()
+	pkg.ID = int(packageID)
+
+	if input.Barcode != "" {
+		b := models.Barcode{Code: input.Barcode, PackageID: &pkg.ID}
+		if err := insertProductBarcode(tx, id, &b); err != nil {
+			respondWithProductError(w, err)
+			return
+		}
+		pkg.Barcode = &b
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, pkg)
+}
+
+// DeleteProductPackage удаляет уровень упаковки продукта вместе с его штрихкодом
+func DeleteProductPackage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+	packageID, err := strconv.Atoi(vars["package_id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid package ID")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM product_packages WHERE id = ? AND product_id = ?", packageID, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Package not found")
+		return
+	}
+	if _, err := tx.Exec("DELETE FROM product_barcodes WHERE package_id = ?", packageID); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Package deleted successfully"})
+}
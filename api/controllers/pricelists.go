@@ -0,0 +1,261 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/mattn/go-sqlite3"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// respondWithPriceListError переводит ошибки прайс-листов в HTTP-ответ
+func respondWithPriceListError(w http.ResponseWriter, err error) {
+	var sqliteErr sqlite3.Error
+	switch {
+	case errors.Is(err, services.ErrPriceListNotFound), errors.Is(err, services.ErrProductNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique:
+		utils.RespondWithError(w, http.StatusConflict, "Price list with this name already exists")
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// validatePriceList проверяет прайс-лист; возвращает текст ошибки или пустую строку
+func validatePriceList(pl *models.PriceList) string {
+	pl.Name = strings.TrimSpace(pl.Name)
+	if pl.Name == "" {
+		return "Price list name is required"
+	}
+	pl.Currency = strings.ToUpper(strings.TrimSpace(pl.Currency))
+	if pl.Currency == "" {
+		pl.Currency = models.DefaultCurrency
+	}
+	if err := services.ValidatePrice(0, 0, pl.Currency); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// GetPriceLists возвращает прайс-листы без цен
+func GetPriceLists(w http.ResponseWriter, r *http.Request) {
+	rows, err := database.DB.Query("SELECT id, name, description, currency, active FROM price_lists ORDER BY name")
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	lists := []models.PriceList{}
+	for rows.Next() {
+		var pl models.PriceList
+		if err := rows.Scan(&pl.ID, &pl.Name, &pl.Description, &pl.Currency, &pl.Active); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		lists = append(lists, pl)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, lists)
+}
+
+// GetPriceList возвращает прайс-лист с ценами продуктов
+func GetPriceList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+
+	pl, err := services.LoadPriceList(database.DB, id, true)
+	if err != nil {
+		respondWithPriceListError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, pl)
+}
+
+// CreatePriceList добавляет прайс-лист
+func CreatePriceList(w http.ResponseWriter, r *http.Request) {
+	pl := models.PriceList{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&pl); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if msg := validatePriceList(&pl); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO price_lists (name, description, currency, active) VALUES (?, ?, ?, ?)
+	`, pl.Name, pl.Description, pl.Currency, pl.Active)
+	if err != nil {
+		respondWithPriceListError(w, err)
+		return
+	}
+	id, _ := result.LastInsertId()
+	pl.ID = int(id)
+	pl.Items = nil
+
+	utils.RespondWithJSON(w, http.StatusCreated, pl)
+}
+
+// UpdatePriceList изменяет название, описание, валюту и активность прайс-листа
+func UpdatePriceList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+
+	pl := models.PriceList{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&pl); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if msg := validatePriceList(&pl); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE price_lists SET name = ?, description = ?, currency = ?, active = ? WHERE id = ?
+	`, pl.Name, pl.Description, pl.Currency, pl.Active, id)
+	if err != nil {
+		respondWithPriceListError(w, err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		respondWithPriceListError(w, services.ErrPriceListNotFound)
+		return
+	}
+
+	pl, err = services.LoadPriceList(database.DB, id, true)
+	if err != nil {
+		respondWithPriceListError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, pl)
+}
+
+// DeletePriceList удаляет прайс-лист вместе с его ценами; акции прайс-листа удаляются
+func DeletePriceList(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM promotions WHERE price_list_id = ?", id); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if _, err := tx.Exec("DELETE FROM price_list_items WHERE price_list_id = ?", id); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result, err := tx.Exec("DELETE FROM price_lists WHERE id = ?", id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		respondWithPriceListError(w, services.ErrPriceListNotFound)
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Price list deleted successfully"})
+}
+
+// SetPriceListItem устанавливает цену продукта в прайс-листе за единицу измерения продукта
+func SetPriceListItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+
+	var item models.PriceListItem
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if item.Price < 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, services.ErrInvalidPrice.Error())
+		return
+	}
+	item.Price = utils.RoundMoney(item.Price)
+
+	if _, err := services.LoadPriceList(database.DB, id, false); err != nil {
+		respondWithPriceListError(w, err)
+		return
+	}
+	err = database.DB.QueryRow("SELECT name FROM products WHERE id = ?", item.ProductID).Scan(&item.ProductName)
+	if err != nil {
+		respondWithPriceListError(w, services.ErrProductNotFound)
+		return
+	}
+
+	_, err = database.DB.Exec(`
+		INSERT INTO price_list_items (price_list_id, product_id, price) VALUES (?, ?, ?)
+		ON CONFLICT(price_list_id, product_id) DO UPDATE SET price = excluded.price
+	`, id, item.ProductID, item.Price)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, item)
+}
+
+// DeletePriceListItem убирает продукт из прайс-листа; дальше действует цена продажи продукта
+func DeletePriceListItem(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid price list ID")
+		return
+	}
+	productID, err := strconv.Atoi(vars["product_id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM price_list_items WHERE price_list_id = ? AND product_id = ?", id, productID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Price list item not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Price list item deleted successfully"})
+}
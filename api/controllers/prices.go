@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// SetProductPrice изменяет цены продукта. Если effective_from указывает на будущее,
+// изменение планируется и применяется фоновой задачей в указанный момент.
+func SetProductPrice(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	var input struct {
+		CostPrice     *float64 `json:"cost_price"`
+		SalePrice     *float64 `json:"sale_price"`
+		Currency      string   `json:"currency"`
+		EffectiveFrom string   `json:"effective_from"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	effectiveFrom := time.Now()
+	if input.EffectiveFrom != "" {
+		effectiveFrom, err = utils.ParseDateTime(input.EffectiveFrom)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var price models.ScheduledPrice
+	err = tx.QueryRow("SELECT id, name FROM products WHERE id = ?", id).Scan(&price.ProductID, &price.ProductName)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	price.CostPrice = input.CostPrice
+	price.SalePrice = input.SalePrice
+	if input.Currency != "" {
+		price.Currency = &input.Currency
+	}
+	if price.CostPrice == nil && price.SalePrice == nil && price.Currency == nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Nothing to change: pass cost_price, sale_price or currency")
+		return
+	}
+
+	// Не переданные поля берутся из текущих цен
+	costPrice, salePrice, currency, err := services.ResolvePrice(tx, id, price.CostPrice, price.SalePrice, price.Currency)
+	if err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+	if err := services.ValidatePrice(costPrice, salePrice, currency); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// Запланированное изменение хранит только переданные поля, чтобы не откатить
+	// изменения остальных цен, сделанные до его применения
+	if effectiveFrom.After(time.Now()) {
+		price.EffectiveFrom = utils.FormatDBTime(effectiveFrom)
+		price.CreatedBy = currentUsername(r)
+		result, err := tx.Exec(`
+			INSERT INTO scheduled_prices (product_id, cost_price, sale_price, currency, effective_from, created_by)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, id, price.CostPrice, price.SalePrice, price.Currency, price.EffectiveFrom, price.CreatedBy)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		scheduledID, _ := result.LastInsertId()
+		price.ID = int(scheduledID)
+		if err := tx.QueryRow("SELECT created_at FROM scheduled_prices WHERE id = ?", price.ID).Scan(&price.CreatedAt); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		utils.RespondWithJSON(w, http.StatusAccepted, price)
+		return
+	}
+
+	err = services.ApplyPrice(tx, id, costPrice, salePrice, currency, currentUsername(r), effectiveFrom)
+	if err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+
+	p, err := loadProduct(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, p)
+}
+
+// GetPriceHistory возвращает историю изменения цен продукта
+func GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT id, product_id, old_cost_price, old_sale_price, cost_price, sale_price, currency,
+			effective_from, changed_by, changed_at
+		FROM price_history
+		WHERE product_id = ?
+		ORDER BY changed_at DESC, id DESC
+	`, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	history := []models.PriceChange{}
+	for rows.Next() {
+		var c models.PriceChange
+		err := rows.Scan(&c.ID, &c.ProductID, &c.OldCostPrice, &c.OldSalePrice, &c.CostPrice, &c.SalePrice, &c.Currency,
+			&c.EffectiveFrom, &c.ChangedBy, &c.ChangedAt)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		history = append(history, c)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, history)
+}
+
+// GetScheduledPrices возвращает ожидающие применения изменения цен
+func GetScheduledPrices(w http.ResponseWriter, r *http.Request) {
+	rows, err := database.DB.Query(`
+		SELECT s.id, s.product_id, p.name, s.cost_price, s.sale_price, s.currency, s.effective_from, s.created_by, s.created_at
+		FROM scheduled_prices s
+		JOIN products p ON s.product_id = p.id
+		WHERE s.applied_at IS NULL AND s.cancelled_at IS NULL
+		ORDER BY s.effective_from, s.id
+	`)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	prices := []models.ScheduledPrice{}
+	for rows.Next() {
+		var s models.ScheduledPrice
+		err := rows.Scan(&s.ID, &s.ProductID, &s.ProductName, &s.CostPrice, &s.SalePrice, &s.Currency,
+			&s.EffectiveFrom, &s.CreatedBy, &s.CreatedAt)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		prices = append(prices, s)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, prices)
+}
+
+// CancelScheduledPrice отменяет ещё не применённое изменение цены
+func CancelScheduledPrice(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid scheduled price ID")
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE scheduled_prices SET cancelled_at = CURRENT_TIMESTAMP
+		WHERE id = ? AND applied_at IS NULL AND cancelled_at IS NULL
+	`, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Pending scheduled price not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Scheduled price cancelled"})
+}
@@ -0,0 +1,180 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// GetProductionRuns возвращает журнал выпусков (?product_id= и ?dish_id= — фильтры)
+func GetProductionRuns(w http.ResponseWriter, r *http.Request) {
+	query := "SELECT id FROM production_runs WHERE 1 = 1"
+	var args []interface{}
+	for _, filter := range []string{"product_id", "dish_id"} {
+		value := r.URL.Query().Get(filter)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid "+filter)
+			return
+		}
+		query += " AND " + filter + " = ?"
+		args = append(args, id)
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	runs := []models.ProductionRun{}
+	for _, id := range ids {
+		run, err := services.LoadProductionRun(database.DB, id)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		runs = append(runs, run)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, runs)
+}
+
+// GetProductionRun возвращает выпуск со списанными ингредиентами
+func GetProductionRun(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid production run ID")
+		return
+	}
+
+	run, err := services.LoadProductionRun(database.DB, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Production run not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, run)
+}
+
+// CreateProductionRun проводит выпуск полуфабриката: рецептура dish_id на batches выходов,
+// фактический выход actual_quantity в единице unit_id (по умолчанию — в единице продукта).
+// Выпуск можно оприходовать в место хранения и в партию с номером и сроком годности;
+// source_location_id — место хранения, из которого списываются ингредиенты.
+func CreateProductionRun(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		DishID           int     `json:"dish_id"`
+		ProductID        int     `json:"product_id"`
+		Batches          float64 `json:"batches"`
+		ActualQuantity   float64 `json:"actual_quantity"`
+		UnitID           int     `json:"unit_id"`
+		LocationID       *int    `json:"location_id"`
+		SourceLocationID *int    `json:"source_location_id"`
+		LotNumber        string  `json:"lot_number"`
+		ExpiryDate       *string `json:"expiry_date"`
+		Note             string  `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.ExpiryDate != nil && !isValidDate(*input.ExpiryDate) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid expiry date (expected YYYY-MM-DD)")
+		return
+	}
+	if input.Batches <= 0 || input.ActualQuantity < 0 {
+		respondWithStockError(w, services.ErrInvalidQuantity)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	// Нулевой выход допустим: партия испорчена, ингредиенты всё равно списываются
+	actual := 0
+	if input.ActualQuantity > 0 {
+		actual, err = inputToBase(tx, input.ProductID, input.UnitID, input.ActualQuantity)
+		if err != nil {
+			respondWithStockError(w, err)
+			return
+		}
+	}
+
+	run := models.ProductionRun{
+		DishID:           input.DishID,
+		ProductID:        input.ProductID,
+		Batches:          input.Batches,
+		ActualQuantity:   actual,
+		LocationID:       input.LocationID,
+		SourceLocationID: input.SourceLocationID,
+		Note:             input.Note,
+		CreatedBy:        currentUsername(r),
+	}
+	if actual > 0 {
+		lotID, err := services.FindOrCreateLot(tx, input.ProductID, input.LotNumber, input.ExpiryDate)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if lotID != 0 {
+			run.LotID = &lotID
+		}
+	}
+
+	if err := services.RunProduction(tx, &run); err != nil {
+		switch {
+		case errors.Is(err, services.ErrDishNotFound):
+			utils.RespondWithError(w, http.StatusNotFound, err.Error())
+		case errors.Is(err, services.ErrUnknownRecipeYield):
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		default:
+			respondWithOrderError(w, err)
+		}
+		return
+	}
+
+	run, err = services.LoadProductionRun(tx, run.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, run)
+}
@@ -3,22 +3,35 @@ package controllers
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/mattn/go-sqlite3"
 	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/middleware"
 	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
 	"wuwunchik.github.io/api/utils"
 )
 
-// GetPublicProducts возвращает список продуктов без авторизации
+// GetPublicProducts возвращает список продуктов без авторизации (поддерживает фильтры productFilter)
 func GetPublicProducts(w http.ResponseWriter, r *http.Request) {
+	where, args, err := productFilter(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	rows, err := database.DB.Query(`
-			SELECT p.id, p.name, p.quantity, u.name as unit_name, u.abbreviation
+			SELECT p.id, p.name, p.sku, p.quantity, u.name as unit_name, u.abbreviation
 			FROM products p
 			JOIN units u ON p.unit_id = u.id
-	`)
+	`+where, args...)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -28,6 +41,7 @@ func GetPublicProducts(w http.ResponseWriter, r *http.Request) {
 	type PublicProduct struct {
 		ID       int    `json:"id"`
 		Name     string `json:"name"`
+		SKU      string `json:"sku"`
 		Quantity int    `json:"quantity"`
 		Unit     string `json:"unit_name"`
 		Abbr     string `json:"abbreviation"`
@@ -36,7 +50,7 @@ func GetPublicProducts(w http.ResponseWriter, r *http.Request) {
 	var products []PublicProduct
 	for rows.Next() {
 		var p PublicProduct
-		if err := rows.Scan(&p.ID, &p.Name, &p.Quantity, &p.Unit, &p.Abbr); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.SKU, &p.Quantity, &p.Unit, &p.Abbr); err != nil {
 			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
@@ -46,13 +60,380 @@ func GetPublicProducts(w http.ResponseWriter, r *http.Request) {
 	utils.RespondWithJSON(w, http.StatusOK, products)
 }
 
-// GetProducts возвращает список всех продуктов
-func GetProducts(w http.ResponseWriter, r *http.Request) {
-	rows, err := database.DB.Query(`
-		SELECT p.id, p.name, p.quantity, p.unit_id, u.id, u.name, u.abbreviation
-		FROM products p
-		JOIN units u ON p.unit_id = u.id
+// productSelect — общий запрос продукта вместе с его единицей измерения
+const productSelect = `
+	SELECT p.id, p.name, p.sku, p.quantity, p.unit_id, p.min_level, p.reorder_point, p.target_level, p.category_id,
+		p.cost_price, p.sale_price, p.currency,
+		p.energy_kcal, p.protein, p.fat, p.carbohydrates,
+		u.id, u.name, u.abbreviation, u.dimension, u.factor
+	FROM products p
+	JOIN units u ON p.unit_id = u.id
+`
+
+// rowScanner — общий интерфейс *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProduct считывает продукт, выбранный запросом productSelect
+func scanProduct(row rowScanner) (models.Product, error) {
+	var p models.Product
+	var energy, protein, fat, carbohydrates *float64
+	err := row.Scan(&p.ID, &p.Name, &p.SKU, &p.Quantity, &p.UnitID, &p.MinLevel, &p.ReorderPoint, &p.TargetLevel, &p.CategoryID,
+		&p.CostPrice, &p.SalePrice, &p.Currency,
+		&energy, &protein, &fat, &carbohydrates,
+		&p.Unit.ID, &p.Unit.Name, &p.Unit.Abbreviation, &p.Unit.Dimension, &p.Unit.Factor)
+	if energy != nil {
+		p.Nutrition = &models.Nutrition{EnergyKcal: *energy}
+		if protein != nil {
+			p.Nutrition.Protein = *protein
+		}
+		if fat != nil {
+			p.Nutrition.Fat = *fat
+		}
+		if carbohydrates != nil {
+			p.Nutrition.Carbohydrates = *carbohydrates
+		}
+	}
+	return p, err
+}
+
+// loadProduct загружает продукт вместе со связанными данными
+func loadProduct(q services.Querier, id int) (models.Product, error) {
+	p, err := scanProduct(q.QueryRow(productSelect+" WHERE p.id = ?", id))
+	if err != nil {
+		return p, err
+	}
+	products := []models.Product{p}
+	if err := attachProductDetails(q, products); err != nil {
+		return p, err
+	}
+	return products[0], nil
+}
+
+// productFilter строит условие WHERE для списка продуктов по параметрам запроса:
+// category_id — категория вместе со всеми подкатегориями, tag (можно несколько) — продукт
+// должен иметь все указанные теги
+func productFilter(r *http.Request) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+
+	if value := r.URL.Query().Get("category_id"); value != "" {
+		categoryID, err := strconv.Atoi(value)
+		if err != nil {
+			return "", nil, errors.New("Invalid category ID")
+		}
+		conditions = append(conditions, `p.category_id IN (
+			WITH RECURSIVE subtree (id) AS (
+				SELECT ?
+				UNION ALL
+				SELECT c.id FROM categories c JOIN subtree ON c.parent_id = subtree.id
+			)
+			SELECT id FROM subtree
+		)`)
+		args = append(args, categoryID)
+	}
+
+	for _, tag := range r.URL.Query()["tag"] {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM product_tags pt JOIN tags t ON pt.tag_id = t.id
+			WHERE pt.product_id = p.id AND t.name = ?
+		)`)
+		args = append(args, tag)
+	}
+
+	if len(conditions) == 0 {
+		return "", nil, nil
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args, nil
+}
+
+// setProductTags заменяет теги продукта, создавая новые теги по имени
+func setProductTags(tx *sql.Tx, productID int, tags []string) error {
+	if _, err := tx.Exec("DELETE FROM product_tags WHERE product_id = ?", productID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if _, err := tx.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", tag); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO product_tags (product_id, tag_id)
+			SELECT ?, id FROM tags WHERE name = ?
+		`, productID, tag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setProductAllergens заменяет аллергены продукта
+func setProductAllergens(tx *sql.Tx, productID int, allergens []string) error {
+	if err := services.ValidateAllergens(allergens); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM product_allergens WHERE product_id = ?", productID); err != nil {
+		return err
+	}
+	for _, a := range allergens {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO product_allergens (product_id, allergen) VALUES (?, ?)", productID, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setProductNutrition сохраняет пищевую ценность продукта на 100 г (мл)
+func setProductNutrition(tx *sql.Tx, productID int, n *models.Nutrition) error {
+	if err := services.ValidateNutrition(n); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+		UPDATE products SET energy_kcal = ?, protein = ?, fat = ?, carbohydrates = ? WHERE id = ?
+	`, n.EnergyKcal, n.Protein, n.Fat, n.Carbohydrates, productID)
+	return err
+}
+
+// setProductBarcodes заменяет штрихкоды продукта, проверяя их формат и контрольные цифры.
+// Тип штрихкода определяется автоматически, если не указан.
+func setProductBarcodes(tx *sql.Tx, productID int, barcodes []models.Barcode) error {
+	// Штрихкоды упаковок меняются вместе с упаковками
+	if _, err := tx.Exec("DELETE FROM product_barcodes WHERE product_id = ? AND package_id IS NULL", productID); err != nil {
+		return err
+	}
+	for _, b := range barcodes {
+		b.PackageID = nil
+		if err := insertProductBarcode(tx, productID, &b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insertProductBarcode проверяет и добавляет штрихкод продукта
+func insertProductBarcode(q services.Querier, productID int, b *models.Barcode) error {
+	b.Code = strings.TrimSpace(b.Code)
+	if b.BarcodeType == "" {
+		b.BarcodeType = services.DetectBarcodeType(b.Code)
+		if b.Weighted {
+			b.BarcodeType = services.BarcodeEAN13
+		}
+	}
+	if err := services.ValidateBarcode(b.Code, b.BarcodeType, b.Weighted); err != nil {
+		return err
+	}
+
+	result, err := q.Exec(`
+		INSERT INTO product_barcodes (product_id, code, barcode_type, weighted, package_id) VALUES (?, ?, ?, ?, ?)
+	`, productID, b.Code, b.BarcodeType, b.Weighted, b.PackageID)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	b.ID = int(id)
+	b.ProductID = productID
+	return nil
+}
+
+// setProductPrice применяет цены из запроса; не переданные цены остаются прежними
+func setProductPrice(tx *sql.Tx, r *http.Request, id int, p models.Product) error {
+	if p.CostPrice == nil && p.SalePrice == nil && p.Currency == "" {
+		return nil
+	}
+
+	var cost, sale float64
+	var currency string
+	err := tx.QueryRow("SELECT cost_price, sale_price, currency FROM products WHERE id = ?", id).Scan(&cost, &sale, &currency)
+	if err != nil {
+		return err
+	}
+	if p.CostPrice != nil {
+		cost = *p.CostPrice
+	}
+	if p.SalePrice != nil {
+		sale = *p.SalePrice
+	}
+	if p.Currency != "" {
+		currency = p.Currency
+	}
+
+	return services.ApplyPrice(tx, id, cost, sale, currency, currentUsername(r), time.Now())
+}
+
+// applyPriceVisibility скрывает себестоимость от всех, кроме админов и менеджеров
+func applyPriceVisibility(r *http.Request, products []models.Product) {
+	if middleware.HasRole(r, "admin", "manager") {
+		return
+	}
+	for i := range products {
+		products[i].CostPrice = nil
+	}
+}
+
+// respondWithProductError переводит ошибки сохранения продукта в HTTP-ответ
+func respondWithProductError(w http.ResponseWriter, err error) {
+	var sqliteErr sqlite3.Error
+	switch {
+	case errors.Is(err, services.ErrInvalidBarcode), errors.Is(err, services.ErrInvalidChecksum),
+		errors.Is(err, services.ErrInvalidPrice), errors.Is(err, services.ErrUnknownAllergen),
+		errors.Is(err, services.ErrInvalidNutrition):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique:
+		utils.RespondWithError(w, http.StatusConflict, "SKU or barcode is already used by another product")
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// validateProductCategory проверяет, что категория продукта существует
+func validateProductCategory(q services.Querier, categoryID *int) string {
+	if categoryID == nil {
+		return ""
+	}
+	var exists int
+	if err := q.QueryRow("SELECT COUNT(*) FROM categories WHERE id = ?", *categoryID).Scan(&exists); err != nil {
+		return err.Error()
+	}
+	if exists == 0 {
+		return "Category not found"
+	}
+	return ""
+}
+
+// attachProductDetails дополняет продукты тегами, аллергенами, штрихкодами, упаковками,
+// остатками по местам хранения и резервами
+func attachProductDetails(q services.Querier, products []models.Product) error {
+	stock, err := services.LocationStock(q)
+	if err != nil {
+		return err
+	}
+	reserved, err := services.ReservedQuantities(q)
+	if err != nil {
+		return err
+	}
+	blocked, err := services.BlockedQuantities(q)
+	if err != nil {
+		return err
+	}
+
+	rows, err := q.Query(`
+		SELECT pt.product_id, t.name FROM product_tags pt
+		JOIN tags t ON pt.tag_id = t.id
+		ORDER BY t.name
 	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	tags := make(map[int][]string)
+	for rows.Next() {
+		var productID int
+		var tag string
+		if err := rows.Scan(&productID, &tag); err != nil {
+			return err
+		}
+		tags[productID] = append(tags[productID], tag)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = q.Query("SELECT product_id, allergen FROM product_allergens ORDER BY allergen")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	allergens := make(map[int][]string)
+	for rows.Next() {
+		var productID int
+		var allergen string
+		if err := rows.Scan(&productID, &allergen); err != nil {
+			return err
+		}
+		allergens[productID] = append(allergens[productID], allergen)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = q.Query("SELECT id, product_id, code, barcode_type, weighted, package_id FROM product_barcodes ORDER BY id")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	barcodes := make(map[int][]models.Barcode)
+	packageBarcodes := make(map[int]models.Barcode)
+	for rows.Next() {
+		var b models.Barcode
+		if err := rows.Scan(&b.ID, &b.ProductID, &b.Code, &b.BarcodeType, &b.Weighted, &b.PackageID); err != nil {
+			return err
+		}
+		if b.PackageID != nil {
+			packageBarcodes[*b.PackageID] = b
+			continue
+		}
+		barcodes[b.ProductID] = append(barcodes[b.ProductID], b)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = q.Query("SELECT id, product_id, name, quantity FROM product_packages ORDER BY quantity")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	packages := make(map[int][]models.ProductPackage)
+	for rows.Next() {
+		var pkg models.ProductPackage
+		if err := rows.Scan(&pkg.ID, &pkg.ProductID, &pkg.Name, &pkg.Quantity); err != nil {
+			return err
+		}
+		if b, ok := packageBarcodes[pkg.ID]; ok {
+			pkg.Barcode = &b
+		}
+		packages[pkg.ProductID] = append(packages[pkg.ProductID], pkg)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range products {
+		p := &products[i]
+		p.Tags = tags[p.ID]
+		p.Allergens = allergens[p.ID]
+		p.Barcodes = barcodes[p.ID]
+		p.Packages = packages[p.ID]
+		if len(p.Packages) > 0 {
+			breakdown := services.BreakdownStock(p.Quantity, p.Packages, p.Unit)
+			p.StockBreakdown = &breakdown
+		}
+		p.Locations = stock[p.ID]
+		p.Unassigned = p.Quantity
+		for _, ls := range p.Locations {
+			p.Unassigned -= ls.Quantity
+		}
+		p.Reserved = reserved[p.ID]
+		p.Blocked = blocked[p.ID]
+		p.Available = p.Quantity - p.Reserved - p.Blocked
+	}
+	return nil
+}
+
+// GetProducts возвращает список всех продуктов (поддерживает фильтры productFilter)
+func GetProducts(w http.ResponseWriter, r *http.Request) {
+	where, args, err := productFilter(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := database.DB.Query(productSelect+where, args...)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -61,16 +442,18 @@ func GetProducts(w http.ResponseWriter, r *http.Request) {
 
 	var products []models.Product
 	for rows.Next() {
-		var p models.Product
-		var unit models.Unit
-		err := rows.Scan(&p.ID, &p.Name, &p.Quantity, &p.UnitID, &unit.ID, &unit.Name, &unit.Abbreviation)
+		p, err := scanProduct(rows)
 		if err != nil {
 			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 			return
 		}
-		p.Unit = unit
 		products = append(products, p)
 	}
+	if err := attachProductDetails(database.DB, products); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	applyPriceVisibility(r, products)
 
 	utils.RespondWithJSON(w, http.StatusOK, products)
 }
@@ -84,14 +467,7 @@ func GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var p models.Product
-	var unit models.Unit
-	err = database.DB.QueryRow(`
-		SELECT p.id, p.name, p.quantity, p.unit_id, u.id, u.name, u.abbreviation
-		FROM products p
-		JOIN units u ON p.unit_id = u.id
-		WHERE p.id = ?
-	`, id).Scan(&p.ID, &p.Name, &p.Quantity, &p.UnitID, &unit.ID, &unit.Name, &unit.Abbreviation)
+	p, err := loadProduct(database.DB, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			utils.RespondWithError(w, http.StatusNotFound, "Product not found")
@@ -100,12 +476,14 @@ func GetProduct(w http.ResponseWriter, r *http.Request) {
 		}
 		return
 	}
-	p.Unit = unit
+	products := []models.Product{p}
+	applyPriceVisibility(r, products)
 
-	utils.RespondWithJSON(w, http.StatusOK, p)
+	utils.RespondWithJSON(w, http.StatusOK, products[0])
 }
 
-// CreateProduct создает новый продукт
+// CreateProduct создает новый продукт. Начальное количество проводится
+// как корректировка остатка, чтобы оно попало в журнал движения товаров.
 func CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var p models.Product
 	err := json.NewDecoder(r.Body).Decode(&p)
@@ -113,29 +491,94 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if msg := validateLevels(p.MinLevel, p.ReorderPoint, p.TargetLevel); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if p.Quantity < 0 {
+		respondWithStockError(w, services.ErrInvalidQuantity)
+		return
+	}
+	if msg := validateProductCategory(database.DB, p.CategoryID); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
 
-	result, err := database.DB.Exec("INSERT INTO products (name, quantity, unit_id) VALUES (?, ?, ?)", p.Name, p.Quantity, p.UnitID)
+	tx, err := database.DB.Begin()
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO products (name, sku, quantity, unit_id, min_level, reorder_point, target_level, category_id)
+		VALUES (?, ?, 0, ?, ?, ?, ?, ?)
+	`, p.Name, strings.TrimSpace(p.SKU), p.UnitID, p.MinLevel, p.ReorderPoint, p.TargetLevel, p.CategoryID)
+	if err != nil {
+		respondWithProductError(w, err)
+		return
+	}
 
 	id, _ := result.LastInsertId()
-	p.ID = int(id)
+	if err := setProductTags(tx, int(id), p.Tags); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := setProductBarcodes(tx, int(id), p.Barcodes); err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+	if err := setProductAllergens(tx, int(id), p.Allergens); err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+	if p.Nutrition != nil {
+		if err := setProductNutrition(tx, int(id), p.Nutrition); err != nil {
+			respondWithProductError(w, err)
+			return
+		}
+	}
+	if err := setProductPrice(tx, r, int(id), p); err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+	if p.Quantity > 0 {
+		movement := models.StockMovement{
+			ProductID:    int(id),
+			MovementType: models.MovementAdjustment,
+			Quantity:     p.Quantity,
+			Note:         "Начальный остаток",
+			CreatedBy:    currentUsername(r),
+		}
+		if err := services.PostMovement(tx, &movement); err != nil {
+			respondWithStockError(w, err)
+			return
+		}
+	}
 
 	// Получаем информацию о единице измерения для возврата полной информации о продукте
-	var unit models.Unit
-	err = database.DB.QueryRow("SELECT id, name, abbreviation FROM units WHERE id = ?", p.UnitID).Scan(&unit.ID, &unit.Name, &unit.Abbreviation)
+	p, err = loadProduct(tx, int(id))
 	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusBadRequest, "Unit not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	p.Unit = unit
 
 	utils.RespondWithJSON(w, http.StatusCreated, p)
 }
 
-// UpdateProduct обновляет информацию о продукте
+// UpdateProduct обновляет информацию о продукте: меняются только переданные поля
+// (category_id: null снимает категорию). Переданное количество проводится корректировкой
+// остатка без места хранения (уменьшение — по правилу services.PostIssue).
 func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -144,29 +587,172 @@ func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var p models.Product
-	err = json.NewDecoder(r.Body).Decode(&p)
+	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	var p models.Product
+	if err := json.Unmarshal(body, &p); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// Поля, которые нужно отличать от непереданных
+	var sent struct {
+		Name         *string         `json:"name"`
+		SKU          *string         `json:"sku"`
+		Quantity     *int            `json:"quantity"`
+		UnitID       *int            `json:"unit_id"`
+		MinLevel     *int            `json:"min_level"`
+		ReorderPoint *int            `json:"reorder_point"`
+		TargetLevel  *int            `json:"target_level"`
+		CategoryID   json.RawMessage `json:"category_id"`
+	}
+	if err := json.Unmarshal(body, &sent); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	_, err = database.DB.Exec("UPDATE products SET name = ?, quantity = ?, unit_id = ? WHERE id = ?", p.Name, p.Quantity, p.UnitID, id)
+	tx, err := database.DB.Begin()
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
+	defer tx.Rollback()
+
+	current, err := scanProduct(tx.QueryRow(productSelect+" WHERE p.id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Product not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if sent.Name == nil {
+		p.Name = current.Name
+	}
+	if sent.SKU == nil {
+		p.SKU = current.SKU
+	}
+	if sent.UnitID == nil {
+		p.UnitID = current.UnitID
+	}
+	if sent.MinLevel == nil {
+		p.MinLevel = current.MinLevel
+	}
+	if sent.ReorderPoint == nil {
+		p.ReorderPoint = current.ReorderPoint
+	}
+	if sent.TargetLevel == nil {
+		p.TargetLevel = current.TargetLevel
+	}
+	if sent.CategoryID == nil {
+		p.CategoryID = current.CategoryID
+	}
+	if msg := validateLevels(p.MinLevel, p.ReorderPoint, p.TargetLevel); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if msg := validateProductCategory(tx, p.CategoryID); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// Пересчёт единиц и упаковки заданы в базовых единицах продукта, поэтому
+	// размерность его единицы нельзя менять, пока они существуют
+	var conversions int
+	err = tx.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM product_unit_conversions WHERE product_id = ?2)
+			+ (SELECT COUNT(*) FROM product_packages WHERE product_id = ?2)
+		FROM products p
+		JOIN units old ON p.unit_id = old.id
+		JOIN units new ON new.id = ?1
+		WHERE p.id = ?2 AND old.dimension != new.dimension
+	`, p.UnitID, id).Scan(&conversions)
+	if err != nil && err != sql.ErrNoRows {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if conversions > 0 {
+		utils.RespondWithError(w, http.StatusConflict, "Product has unit conversions or packages; unit dimension cannot be changed")
+		return
+	}
+
+	_, err = tx.Exec(`
+		UPDATE products
+		SET name = ?, sku = ?, unit_id = ?, min_level = ?, reorder_point = ?, target_level = ?, category_id = ?
+		WHERE id = ?
+	`, p.Name, strings.TrimSpace(p.SKU), p.UnitID, p.MinLevel, p.ReorderPoint, p.TargetLevel, p.CategoryID, id)
+	if err != nil {
+		respondWithProductError(w, err)
+		return
+	}
+	// Теги и штрихкоды заменяются, только если переданы в запросе
+	if p.Tags != nil {
+		if err := setProductTags(tx, id, p.Tags); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	if p.Barcodes != nil {
+		if err := setProductBarcodes(tx, id, p.Barcodes); err != nil {
+			respondWithProductError(w, err)
+			return
+		}
+	}
+	// Аллергены и пищевая ценность — тоже
+	if p.Allergens != nil {
+		if err := setProductAllergens(tx, id, p.Allergens); err != nil {
+			respondWithProductError(w, err)
+			return
+		}
+	}
+	if p.Nutrition != nil {
+		if err := setProductNutrition(tx, id, p.Nutrition); err != nil {
+			respondWithProductError(w, err)
+			return
+		}
+	}
+	if err := setProductPrice(tx, r, id, p); err != nil {
+		respondWithProductError(w, err)
+		return
+	}
 
-	p.ID = id
+	if sent.Quantity != nil && *sent.Quantity != current.Quantity {
+		movement := models.StockMovement{
+			ProductID:    id,
+			MovementType: models.MovementAdjustment,
+			Quantity:     *sent.Quantity - current.Quantity,
+			Note:         "Изменение количества продукта",
+			CreatedBy:    currentUsername(r),
+		}
+		if movement.Quantity < 0 {
+			_, err = services.PostIssue(tx, movement)
+		} else {
+			err = services.PostMovement(tx, &movement)
+		}
+		if err != nil {
+			respondWithStockError(w, err)
+			return
+		}
+	}
 
 	// Получаем информацию о единице измерения для возврата полной информации о продукте
-	var unit models.Unit
-	err = database.DB.QueryRow("SELECT id, name, abbreviation FROM units WHERE id = ?", p.UnitID).Scan(&unit.ID, &unit.Name, &unit.Abbreviation)
+	p, err = loadProduct(tx, id)
 	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusBadRequest, "Unit not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
-	p.Unit = unit
 
 	utils.RespondWithJSON(w, http.StatusOK, p)
 }
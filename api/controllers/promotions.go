@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// respondWithPricingError переводит ошибки акций и расчёта корзины в HTTP-ответ
+func respondWithPricingError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrPromotionNotFound), errors.Is(err, services.ErrPriceListNotFound),
+		errors.Is(err, services.ErrProductNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrPriceListInactive), errors.Is(err, services.ErrCurrencyMismatch),
+		errors.Is(err, services.ErrInvalidQuantity):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// validatePromotion проверяет акцию и приводит даты к формату БД (ends_at без времени включает весь день);
+// возвращает текст ошибки или пустую строку
+func validatePromotion(q services.Querier, p *models.Promotion) (string, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return "Promotion name is required", nil
+	}
+	switch p.PromoType {
+	case models.PromotionPercentage:
+		if p.Value <= 0 || p.Value > 100 {
+			return "Percentage must be between 0 and 100", nil
+		}
+		p.BuyQuantity, p.FreeQuantity = 0, 0
+	case models.PromotionFixed:
+		if p.Value <= 0 {
+			return "Discount amount must be positive", nil
+		}
+		p.Value = utils.RoundMoney(p.Value)
+		p.BuyQuantity, p.FreeQuantity = 0, 0
+	case models.PromotionBuyXGetY:
+		if p.BuyQuantity <= 0 || p.FreeQuantity <= 0 {
+			return "buy_quantity and free_quantity must be positive", nil
+		}
+		if p.ProductID == nil {
+			return "product_id is required for buy_x_get_y promotions", nil
+		}
+		p.Value = 0
+	default:
+		return "promo_type must be percentage, fixed or buy_x_get_y", nil
+	}
+
+	startsAt := time.Now().UTC()
+	if p.StartsAt != "" {
+		t, err := utils.ParseDateTime(p.StartsAt)
+		if err != nil {
+			return "starts_at: " + err.Error(), nil
+		}
+		startsAt = t
+	}
+	p.StartsAt = utils.FormatDBTime(startsAt)
+	if p.EndsAt != nil {
+		endsAt, err := utils.ParseDateTime(*p.EndsAt)
+		if err != nil {
+			return "ends_at: " + err.Error(), nil
+		}
+		if isValidDate(*p.EndsAt) {
+			endsAt = endsAt.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		if endsAt.Before(startsAt) {
+			return "ends_at must not be before starts_at", nil
+		}
+		formatted := utils.FormatDBTime(endsAt)
+		p.EndsAt = &formatted
+	}
+
+	var exists int
+	if p.ProductID != nil {
+		if err := q.QueryRow("SELECT COUNT(*) FROM products WHERE id = ?", *p.ProductID).Scan(&exists); err != nil {
+			return "", err
+		}
+		if exists == 0 {
+			return "Product not found", nil
+		}
+	}
+	if p.PriceListID != nil {
+		if err := q.QueryRow("SELECT COUNT(*) FROM price_lists WHERE id = ?", *p.PriceListID).Scan(&exists); err != nil {
+			return "", err
+		}
+		if exists == 0 {
+			return "Price list not found", nil
+		}
+	}
+	return "", nil
+}
+
+// GetPromotions возвращает акции; active=true — только действующие сейчас,
+// price_list_id — акции прайс-листа и общие
+func GetPromotions(w http.ResponseWriter, r *http.Request) {
+	var conditions []string
+	var args []interface{}
+	if r.URL.Query().Get("active") == "true" {
+		now := utils.FormatDBTime(time.Now())
+		conditions = append(conditions, "active = 1 AND starts_at <= ? AND (ends_at IS NULL OR ends_at >= ?)")
+		args = append(args, now, now)
+	}
+	if value := r.URL.Query().Get("price_list_id"); value != "" {
+		priceListID, err := strconv.Atoi(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid price list ID")
+			return
+		}
+		conditions = append(conditions, "(price_list_id IS NULL OR price_list_id = ?)")
+		args = append(args, priceListID)
+	}
+
+	promotions, err := services.FindPromotions(database.DB, strings.Join(conditions, " AND "), args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, promotions)
+}
+
+// GetPromotion возвращает акцию
+func GetPromotion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	p, err := services.LoadPromotion(database.DB, id)
+	if err != nil {
+		respondWithPricingError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, p)
+}
+
+// CreatePromotion добавляет акцию; без starts_at акция начинает действовать сразу
+func CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	p := models.Promotion{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	msg, err := validatePromotion(database.DB, &p)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO promotions (name, promo_type, value, buy_quantity, free_quantity, product_id, price_list_id,
+			starts_at, ends_at, active)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, p.Name, p.PromoType, p.Value, p.BuyQuantity, p.FreeQuantity, p.ProductID, p.PriceListID,
+		p.StartsAt, p.EndsAt, p.Active)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	p, err = services.LoadPromotion(database.DB, int(id))
+	if err != nil {
+		respondWithPricingError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, p)
+}
+
+// UpdatePromotion изменяет акцию; уже оплаченные заказы не пересчитываются
+func UpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	p := models.Promotion{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	msg, err := validatePromotion(database.DB, &p)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE promotions SET name = ?, promo_type = ?, value = ?, buy_quantity = ?, free_quantity = ?,
+			product_id = ?, price_list_id = ?, starts_at = ?, ends_at = ?, active = ?
+		WHERE id = ?
+	`, p.Name, p.PromoType, p.Value, p.BuyQuantity, p.FreeQuantity, p.ProductID, p.PriceListID,
+		p.StartsAt, p.EndsAt, p.Active, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		respondWithPricingError(w, services.ErrPromotionNotFound)
+		return
+	}
+
+	p, err = services.LoadPromotion(database.DB, id)
+	if err != nil {
+		respondWithPricingError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, p)
+}
+
+// DeletePromotion удаляет акцию
+func DeletePromotion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid promotion ID")
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM promotions WHERE id = ?", id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		respondWithPricingError(w, services.ErrPromotionNotFound)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Promotion deleted successfully"})
+}
+
+// PriceBasket рассчитывает корзину по прайс-листу (без него — по ценам продажи продуктов)
+// с действующими акциями и пояснением каждой применённой скидки
+func PriceBasket(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		PriceListID *int                `json:"price_list_id"`
+		At          string              `json:"at"`
+		Items       []models.BasketItem `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(input.Items) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Basket must contain at least one item")
+		return
+	}
+	at := time.Now().UTC()
+	if input.At != "" {
+		t, err := utils.ParseDateTime(input.At)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "at: "+err.Error())
+			return
+		}
+		at = t
+	}
+
+	basket, err := services.PriceBasket(database.DB, input.PriceListID, input.Items, at)
+	if err != nil {
+		respondWithPricingError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, basket)
+}
@@ -0,0 +1,592 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// loadPurchaseOrder загружает заказ поставщику со строками и приёмками
+func loadPurchaseOrder(q services.Querier, id int) (models.PurchaseOrder, error) {
+	var po models.PurchaseOrder
+	err := q.QueryRow(`
+		SELECT po.id, po.supplier_id, s.name, po.status, po.note, po.expected_at,
+			po.created_by, po.created_at, po.sent_at, po.closed_at
+		FROM purchase_orders po
+		JOIN suppliers s ON po.supplier_id = s.id
+		WHERE po.id = ?
+	`, id).Scan(&po.ID, &po.SupplierID, &po.SupplierName, &po.Status, &po.Note, &po.ExpectedAt,
+		&po.CreatedBy, &po.CreatedAt, &po.SentAt, &po.ClosedAt)
+	if err != nil {
+		return po, err
+	}
+
+	rows, err := q.Query(`
+		SELECT l.id, l.product_id, p.name, l.quantity, l.unit_id, l.price, l.base_quantity, l.received_quantity
+		FROM purchase_order_lines l
+		JOIN products p ON l.product_id = p.id
+		WHERE l.order_id = ?
+		ORDER BY l.id
+	`, id)
+	if err != nil {
+		return po, err
+	}
+	for rows.Next() {
+		var l models.PurchaseOrderLine
+		err := rows.Scan(&l.ID, &l.ProductID, &l.ProductName, &l.Quantity, &l.UnitID, &l.Price, &l.BaseQuantity, &l.ReceivedQuantity)
+		if err != nil {
+			rows.Close()
+			return po, err
+		}
+		po.Total += l.Quantity * l.Price
+		po.Lines = append(po.Lines, l)
+	}
+	rows.Close()
+	po.Total = utils.RoundMoney(po.Total)
+
+	rows, err = q.Query(`
+		SELECT r.id, r.line_id, l.product_id, r.quantity, r.unit_id, r.base_quantity, r.price,
+			r.price_difference, r.movement_id, r.received_by, r.received_at
+		FROM purchase_receipts r
+		JOIN purchase_order_lines l ON r.line_id = l.id
+		WHERE r.order_id = ?
+		ORDER BY r.id
+	`, id)
+	if err != nil {
+		return po, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var rc models.PurchaseReceipt
+		err := rows.Scan(&rc.ID, &rc.LineID, &rc.ProductID, &rc.Quantity, &rc.UnitID, &rc.BaseQuantity, &rc.Price,
+			&rc.PriceDifference, &rc.MovementID, &rc.ReceivedBy, &rc.ReceivedAt)
+		if err != nil {
+			return po, err
+		}
+		po.Receipts = append(po.Receipts, rc)
+	}
+
+	return po, rows.Err()
+}
+
+// insertPurchaseLines проверяет и сохраняет строки заказа поставщику.
+// Если единица не указана, используется единица продукта; если не указана цена —
+// последняя цена поставщика за ту же единицу.
+func insertPurchaseLines(tx *sql.Tx, orderID, supplierID int, lines []models.PurchaseOrderLine) error {
+	for _, l := range lines {
+		if l.Quantity <= 0 || l.Price < 0 {
+			return services.ErrInvalidQuantity
+		}
+		if l.UnitID == 0 {
+			err := tx.QueryRow("SELECT unit_id FROM products WHERE id = ?", l.ProductID).Scan(&l.UnitID)
+			if err == sql.ErrNoRows {
+				return services.ErrProductNotFound
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		base, err := services.ConvertToBase(tx, l.ProductID, l.UnitID, l.Quantity)
+		if err != nil {
+			return err
+		}
+		baseQuantity := int(math.Round(base))
+		if baseQuantity <= 0 {
+			return services.ErrInvalidQuantity
+		}
+
+		if l.Price == 0 {
+			err := tx.QueryRow(`
+				SELECT last_price FROM supplier_products
+				WHERE supplier_id = ? AND product_id = ? AND unit_id = ?
+			`, supplierID, l.ProductID, l.UnitID).Scan(&l.Price)
+			if err != nil && err != sql.ErrNoRows {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO purchase_order_lines (order_id, product_id, quantity, unit_id, price, base_quantity)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, orderID, l.ProductID, l.Quantity, l.UnitID, l.Price, baseQuantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPurchaseOrders возвращает список заказов поставщикам (?status= — фильтр по состоянию)
+func GetPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT po.id, po.supplier_id, s.name, po.status, po.note, po.expected_at,
+			po.created_by, po.created_at, po.sent_at, po.closed_at,
+			COALESCE((SELECT SUM(l.quantity * l.price) FROM purchase_order_lines l WHERE l.order_id = po.id), 0)
+		FROM purchase_orders po
+		JOIN suppliers s ON po.supplier_id = s.id
+	`
+	var args []interface{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		query += " WHERE po.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY po.created_at DESC, po.id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	orders := []models.PurchaseOrder{}
+	for rows.Next() {
+		var po models.PurchaseOrder
+		err := rows.Scan(&po.ID, &po.SupplierID, &po.SupplierName, &po.Status, &po.Note, &po.ExpectedAt,
+			&po.CreatedBy, &po.CreatedAt, &po.SentAt, &po.ClosedAt, &po.Total)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		po.Total = utils.RoundMoney(po.Total)
+		orders = append(orders, po)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, orders)
+}
+
+// GetPurchaseOrder возвращает заказ поставщику со строками и приёмками
+func GetPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid purchase order ID")
+		return
+	}
+
+	po, err := loadPurchaseOrder(database.DB, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Purchase order not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, po)
+}
+
+// CreatePurchaseOrder создает черновик заказа поставщику
+func CreatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	var input models.PurchaseOrder
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(input.Lines) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Purchase order must have at least one line")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var suppliers int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM suppliers WHERE id = ?", input.SupplierID).Scan(&suppliers); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if suppliers == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Supplier not found")
+		return
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO purchase_orders (supplier_id, status, note, expected_at, created_by)
+		VALUES (?, ?, ?, ?, ?)
+	`, input.SupplierID, models.PurchaseDraft, input.Note, input.ExpectedAt, currentUsername(r))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	if err := insertPurchaseLines(tx, int(id), input.SupplierID, input.Lines); err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	po, err := loadPurchaseOrder(tx, int(id))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, po)
+}
+
+// UpdatePurchaseOrder заменяет примечание, ожидаемую дату и строки черновика заказа
+func UpdatePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid purchase order ID")
+		return
+	}
+
+	var input models.PurchaseOrder
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(input.Lines) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Purchase order must have at least one line")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	po, err := loadPurchaseOrder(tx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Purchase order not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if po.Status != models.PurchaseDraft {
+		utils.RespondWithError(w, http.StatusConflict, "Only draft purchase orders can be edited")
+		return
+	}
+
+	_, err = tx.Exec("UPDATE purchase_orders SET note = ?, expected_at = ? WHERE id = ?", input.Note, input.ExpectedAt, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if _, err := tx.Exec("DELETE FROM purchase_order_lines WHERE order_id = ?", id); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := insertPurchaseLines(tx, id, po.SupplierID, input.Lines); err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	po, err = loadPurchaseOrder(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, po)
+}
+
+// SendPurchaseOrder переводит черновик заказа в состояние «отправлен поставщику»
+func SendPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	changePurchaseOrderStatus(w, r, []string{models.PurchaseDraft}, models.PurchaseSent,
+		"UPDATE purchase_orders SET status = ?, sent_at = CURRENT_TIMESTAMP WHERE id = ?")
+}
+
+// CancelPurchaseOrder отменяет заказ; уже принятые количества остаются на складе
+func CancelPurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	changePurchaseOrderStatus(w, r,
+		[]string{models.PurchaseDraft, models.PurchaseSent, models.PurchasePartiallyReceived}, models.PurchaseCancelled,
+		"UPDATE purchase_orders SET status = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ?")
+}
+
+// changePurchaseOrderStatus выполняет переход заказа в новое состояние из допустимых
+func changePurchaseOrderStatus(w http.ResponseWriter, r *http.Request, from []string, to string, update string) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid purchase order ID")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM purchase_orders WHERE id = ?", id).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Purchase order not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	allowed := false
+	for _, s := range from {
+		if s == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		utils.RespondWithError(w, http.StatusConflict, "Cannot change purchase order status from "+status+" to "+to)
+		return
+	}
+
+	if _, err := tx.Exec(update, to, id); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	po, err := loadPurchaseOrder(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, po)
+}
+
+// ReceivePurchaseOrder принимает поставку по заказу: проводит приход на склад,
+// поддерживает частичные поставки и фиксирует расхождение фактической цены с заказанной
+func ReceivePurchaseOrder(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid purchase order ID")
+		return
+	}
+
+	var input struct {
+		Lines []struct {
+			LineID   int      `json:"line_id"`
+			Quantity float64  `json:"quantity"`
+			UnitID   int      `json:"unit_id"`
+			Price    *float64 `json:"price"` // цена за единицу приёмки; по умолчанию — цена заказа
+			// Приёмка в упаковках: quantity — число упаковок, price — цена упаковки
+			PackageID int `json:"package_id"`
+			// Партия поступления
+			LotNumber  string  `json:"lot_number"`
+			ExpiryDate *string `json:"expiry_date"`
+			// Место хранения, куда принимается товар
+			LocationID int `json:"location_id"`
+		} `json:"lines"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(input.Lines) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Nothing to receive")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	po, err := loadPurchaseOrder(tx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Purchase order not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if po.Status != models.PurchaseSent && po.Status != models.PurchasePartiallyReceived {
+		utils.RespondWithError(w, http.StatusConflict, "Only sent purchase orders can be received")
+		return
+	}
+
+	lines := make(map[int]*models.PurchaseOrderLine, len(po.Lines))
+	for i := range po.Lines {
+		lines[po.Lines[i].ID] = &po.Lines[i]
+	}
+
+	username := currentUsername(r)
+	for _, in := range input.Lines {
+		line, ok := lines[in.LineID]
+		if !ok {
+			utils.RespondWithError(w, http.StatusBadRequest, "Line "+strconv.Itoa(in.LineID)+" does not belong to the purchase order")
+			return
+		}
+		if in.Quantity <= 0 {
+			respondWithStockError(w, services.ErrInvalidQuantity)
+			return
+		}
+		if in.ExpiryDate != nil && !isValidDate(*in.ExpiryDate) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid expiry date (expected YYYY-MM-DD)")
+			return
+		}
+		unitID := in.UnitID
+		if unitID == 0 {
+			unitID = line.UnitID
+		}
+
+		var baseQuantity int
+		if in.PackageID != 0 {
+			baseQuantity, err = services.PackageToBase(tx, line.ProductID, in.PackageID, in.Quantity)
+			if err != nil {
+				respondWithStockError(w, err)
+				return
+			}
+			// Упаковки учитываются в единице строки заказа, цена упаковки — в цену этой единицы
+			packages := in.Quantity
+			unitID = line.UnitID
+			in.Quantity = float64(baseQuantity) * line.Quantity / float64(line.BaseQuantity)
+			if in.Price != nil {
+				price := *in.Price * packages / in.Quantity
+				in.Price = &price
+			}
+		} else {
+			base, err := services.ConvertToBase(tx, line.ProductID, unitID, in.Quantity)
+			if err != nil {
+				respondWithStockError(w, err)
+				return
+			}
+			baseQuantity = int(math.Round(base))
+		}
+		if baseQuantity <= 0 {
+			respondWithStockError(w, services.ErrInvalidQuantity)
+			return
+		}
+		if line.ReceivedQuantity+baseQuantity > line.BaseQuantity {
+			utils.RespondWithError(w, http.StatusBadRequest, "Received quantity exceeds ordered quantity for line "+strconv.Itoa(line.ID))
+			return
+		}
+
+		// Стоимость базовой единицы по заказу и по факту
+		orderedCost := line.Price * line.Quantity / float64(line.BaseQuantity)
+		price := orderedCost * float64(baseQuantity) / in.Quantity
+		if in.Price != nil {
+			if *in.Price < 0 {
+				utils.RespondWithError(w, http.StatusBadRequest, "Price must not be negative")
+				return
+			}
+			price = *in.Price
+		}
+		actualCost := price * in.Quantity / float64(baseQuantity)
+
+		lotID, err := services.FindOrCreateLot(tx, line.ProductID, in.LotNumber, in.ExpiryDate)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		movement := models.StockMovement{
+			ProductID:     line.ProductID,
+			MovementType:  models.MovementReceipt,
+			Quantity:      baseQuantity,
+			UnitCost:      actualCost,
+			LotID:         lotID,
+			LocationID:    in.LocationID,
+			ReferenceType: "purchase_order",
+			ReferenceID:   id,
+			CreatedBy:     username,
+		}
+		if err := services.PostMovement(tx, &movement); err != nil {
+			respondWithStockError(w, err)
+			return
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO purchase_receipts (order_id, line_id, quantity, unit_id, base_quantity, price, price_difference, movement_id, received_by)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, id, line.ID, in.Quantity, unitID, baseQuantity, price,
+			utils.RoundMoney((actualCost-orderedCost)*float64(baseQuantity)), movement.ID, username)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		line.ReceivedQuantity += baseQuantity
+		_, err = tx.Exec("UPDATE purchase_order_lines SET received_quantity = ? WHERE id = ?", line.ReceivedQuantity, line.ID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		// Запоминаем последнюю цену поставщика
+		_, err = tx.Exec(`
+			INSERT INTO supplier_products (supplier_id, product_id, last_price, unit_id)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT (supplier_id, product_id) DO UPDATE SET
+				last_price = excluded.last_price,
+				unit_id = excluded.unit_id
+		`, po.SupplierID, line.ProductID, price, unitID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	status := models.PurchaseReceived
+	for _, l := range po.Lines {
+		if l.ReceivedQuantity < l.BaseQuantity {
+			status = models.PurchasePartiallyReceived
+			break
+		}
+	}
+	if status == models.PurchaseReceived {
+		_, err = tx.Exec("UPDATE purchase_orders SET status = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ?", status, id)
+	} else {
+		_, err = tx.Exec("UPDATE purchase_orders SET status = ? WHERE id = ?", status, id)
+	}
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	po, err = loadPurchaseOrder(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, po)
+}
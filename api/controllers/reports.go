@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// reportDate разбирает параметр date отчёта. Дата без времени включает весь день;
+// без параметра отчёт строится на текущий момент.
+func reportDate(r *http.Request) (time.Time, error) {
+	value := r.URL.Query().Get("date")
+	if value == "" {
+		return time.Now().UTC(), nil
+	}
+	t, err := utils.ParseDateTime(value)
+	if err != nil {
+		return t, err
+	}
+	if isValidDate(value) {
+		t = t.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	return t, nil
+}
+
+// reportPeriod разбирает параметры from и to отчёта за период. Дата без времени в to
+// включает весь день; по умолчанию период — с начала текущего месяца до текущего момента.
+func reportPeriod(r *http.Request) (time.Time, time.Time, error) {
+	now := time.Now().UTC()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	to := now
+
+	if value := r.URL.Query().Get("from"); value != "" {
+		t, err := utils.ParseDateTime(value)
+		if err != nil {
+			return from, to, err
+		}
+		from = t
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		t, err := utils.ParseDateTime(value)
+		if err != nil {
+			return from, to, err
+		}
+		if isValidDate(value) {
+			t = t.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		to = t
+	}
+	if to.Before(from) {
+		return from, to, errors.New("from must not be after to")
+	}
+	return from, to, nil
+}
+
+// formatAmount форматирует сумму для CSV
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// GetValuationReport возвращает оценку складских остатков по FIFO и скользящей средней
+// на дату (?date=YYYY-MM-DD). С параметром format=csv отчёт выгружается в CSV.
+func GetValuationReport(w http.ResponseWriter, r *http.Request) {
+	asOf, err := reportDate(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := services.InventoryValuation(database.DB, asOf)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if r.URL.Query().Get("format") != "csv" {
+		utils.RespondWithJSON(w, http.StatusOK, report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", "attachment; filename=valuation-"+asOf.Format("2006-01-02")+".csv")
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"product_id", "product_name", "quantity", "base_unit", "fifo_value", "average_cost", "average_value"})
+	for _, item := range report.Items {
+		writer.Write([]string{
+			strconv.Itoa(item.ProductID),
+			item.ProductName,
+			strconv.Itoa(item.Quantity),
+			item.BaseUnit,
+			formatAmount(item.FIFOValue),
+			strconv.FormatFloat(item.AverageCost, 'f', 4, 64),
+			formatAmount(item.AverageValue),
+		})
+	}
+	writer.Write([]string{"", "Итого", "", "", formatAmount(report.FIFOTotal), "", formatAmount(report.AverageTotal)})
+	writer.Flush()
+}
+
+// GetWasteReport возвращает стоимость проведённых списаний за период (?from=&to=)
+// по продуктам и причинам. С параметром format=csv выгружаются списания по продуктам.
+func GetWasteReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := reportPeriod(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := services.BuildWasteReport(database.DB, from, to)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if r.URL.Query().Get("format") != "csv" {
+		utils.RespondWithJSON(w, http.StatusOK, report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition",
+		"attachment; filename=waste-"+from.Format("2006-01-02")+"-"+to.Format("2006-01-02")+".csv")
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"product_id", "product_name", "quantity", "base_unit", "cost"})
+	for _, item := range report.ByProduct {
+		writer.Write([]string{
+			strconv.Itoa(item.ProductID),
+			item.ProductName,
+			strconv.Itoa(item.Quantity),
+			item.BaseUnit,
+			formatAmount(item.Cost),
+		})
+	}
+	writer.Write([]string{"", "Итого", "", "", formatAmount(report.Total)})
+	writer.Flush()
+}
+
+// GetComplianceReport возвращает отчёт о соблюдении температурного режима за период
+// (?from=&to=) для проверяющих. С параметром format=csv выгружается сводка по местам хранения.
+func GetComplianceReport(w http.ResponseWriter, r *http.Request) {
+	from, to, err := reportPeriod(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := services.BuildComplianceReport(database.DB, from, to)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if r.URL.Query().Get("format") != "csv" {
+		utils.RespondWithJSON(w, http.StatusOK, report)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition",
+		"attachment; filename=compliance-"+from.Format("2006-01-02")+"-"+to.Format("2006-01-02")+".csv")
+	w.WriteHeader(http.StatusOK)
+
+	formatTemp := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', 1, 64)
+	}
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"storage_unit_id", "name", "unit_type", "min_temp", "max_temp", "readings", "out_of_range",
+		"lowest", "highest", "compliance_percent", "days_without_readings", "alerts", "pending_actions"})
+	for _, c := range report.Units {
+		writer.Write([]string{
+			strconv.Itoa(c.StorageUnitID),
+			c.Name,
+			c.UnitType,
+			formatTemp(&c.MinTemp),
+			formatTemp(&c.MaxTemp),
+			strconv.Itoa(c.Readings),
+			strconv.Itoa(c.OutOfRange),
+			formatTemp(c.LowestRecorded),
+			formatTemp(c.HighestRecorded),
+			strconv.FormatFloat(c.CompliancePercent, 'f', 1, 64),
+			strconv.Itoa(c.DaysWithoutReadings),
+			strconv.Itoa(c.Alerts),
+			strconv.Itoa(c.PendingActions),
+		})
+	}
+	writer.Flush()
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// GetReservations возвращает резервы. Фильтры: ?status=, ?product_id=,
+// ?reference_type= и ?reference_id=.
+func GetReservations(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT rs.id, rs.product_id, p.name, rs.quantity, rs.location_id, rs.reference_type, rs.reference_id,
+			rs.status, rs.expires_at, rs.created_by, rs.created_at, rs.closed_at
+		FROM reservations rs
+		JOIN products p ON rs.product_id = p.id
+	`
+	var conditions []string
+	var args []interface{}
+	params := r.URL.Query()
+	if status := params.Get("status"); status != "" {
+		conditions = append(conditions, "rs.status = ?")
+		args = append(args, status)
+	}
+	if referenceType := params.Get("reference_type"); referenceType != "" {
+		conditions = append(conditions, "rs.reference_type = ?")
+		args = append(args, referenceType)
+	}
+	for _, param := range []string{"product_id", "reference_id"} {
+		value := params.Get(param)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid "+param)
+			return
+		}
+		conditions = append(conditions, "rs."+param+" = ?")
+		args = append(args, id)
+	}
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY rs.created_at DESC, rs.id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	reservations := []models.Reservation{}
+	for rows.Next() {
+		var rs models.Reservation
+		err := rows.Scan(&rs.ID, &rs.ProductID, &rs.ProductName, &rs.Quantity, &rs.LocationID, &rs.ReferenceType, &rs.ReferenceID,
+			&rs.Status, &rs.ExpiresAt, &rs.CreatedBy, &rs.CreatedAt, &rs.ClosedAt)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		reservations = append(reservations, rs)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, reservations)
+}
+
+// CreateReservation резервирует товар под документ (reference_type, reference_id)
+func CreateReservation(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ProductID     int     `json:"product_id"`
+		Quantity      float64 `json:"quantity"`
+		UnitID        int     `json:"unit_id"`
+		LocationID    int     `json:"location_id"`
+		ReferenceType string  `json:"reference_type"`
+		ReferenceID   int     `json:"reference_id"`
+		ExpiresAt     string  `json:"expires_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.ReferenceType == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Reference type is required")
+		return
+	}
+
+	reservation := models.Reservation{
+		ProductID:     input.ProductID,
+		LocationID:    input.LocationID,
+		ReferenceType: input.ReferenceType,
+		ReferenceID:   input.ReferenceID,
+		CreatedBy:     currentUsername(r),
+	}
+	if input.ExpiresAt != "" {
+		expiresAt, err := utils.ParseDateTime(input.ExpiresAt)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if !expiresAt.After(time.Now()) {
+			utils.RespondWithError(w, http.StatusBadRequest, "Expiry must be in the future")
+			return
+		}
+		value := utils.FormatDBTime(expiresAt)
+		reservation.ExpiresAt = &value
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	reservation.Quantity, err = inputToBase(tx, input.ProductID, input.UnitID, input.Quantity)
+	if err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	if err := services.ReserveStock(tx, &reservation); err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, reservation)
+}
+
+// ReleaseReservation снимает действующий резерв
+func ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid reservation ID")
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE reservations SET status = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ? AND status = ?
+	`, models.ReservationReleased, id, models.ReservationActive)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Active reservation not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Reservation released"})
+}
+
+// ReleaseReservationsByReference снимает все резервы документа, например при отмене заказа
+func ReleaseReservationsByReference(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	referenceID, err := strconv.Atoi(vars["reference_id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid reference ID")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	released, err := services.ReleaseReservations(tx, vars["reference_type"], referenceID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]int64{"released": released})
+}
+
+// FulfilReservation списывает зарезервированный товар и закрывает резерв
+func FulfilReservation(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid reservation ID")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	movements, err := services.FulfilReservation(tx, id, currentUsername(r))
+	if err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, movements)
+}
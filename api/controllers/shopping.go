@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// shoppingListParams разбирает параметры списка закупки: lookback_days — период
+// для среднего расхода, days — на сколько дней после поставки должно хватить запаса
+func shoppingListParams(r *http.Request) (int, int, error) {
+	lookback, coverage := services.DefaultLookbackDays, services.DefaultCoverageDays
+	if value := r.URL.Query().Get("lookback_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 1 {
+			return 0, 0, errors.New("lookback_days must be a positive number")
+		}
+		lookback = days
+	}
+	if value := r.URL.Query().Get("days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			return 0, 0, errors.New("days must not be negative")
+		}
+		coverage = days
+	}
+	return lookback, coverage, nil
+}
+
+// GetShoppingList возвращает список закупки по поставщикам
+// (?lookback_days= — период среднего расхода, ?days= — запас в днях)
+func GetShoppingList(w http.ResponseWriter, r *http.Request) {
+	lookback, coverage, err := shoppingListParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	list, err := services.BuildShoppingList(database.DB, lookback, coverage, time.Now().UTC())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, list)
+}
+
+// CreateShoppingPurchaseOrders превращает список закупки в черновики заказов — по одному
+// на поставщика (supplier_ids — только выбранные поставщики). Продукты без известного
+// поставщика пропускаются.
+func CreateShoppingPurchaseOrders(w http.ResponseWriter, r *http.Request) {
+	lookback, coverage, err := shoppingListParams(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var input struct {
+		SupplierIDs []int `json:"supplier_ids"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	selected := make(map[int]bool)
+	for _, id := range input.SupplierIDs {
+		selected[id] = true
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	list, err := services.BuildShoppingList(tx, lookback, coverage, time.Now().UTC())
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	orders := []models.PurchaseOrder{}
+	for _, group := range list.Groups {
+		if group.SupplierID == nil || (len(selected) > 0 && !selected[*group.SupplierID]) {
+			continue
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO purchase_orders (supplier_id, status, note, created_by) VALUES (?, ?, ?, ?)
+		`, *group.SupplierID, models.PurchaseDraft, "Сформирован по списку закупки", currentUsername(r))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		id, _ := result.LastInsertId()
+
+		lines := make([]models.PurchaseOrderLine, 0, len(group.Lines))
+		for _, l := range group.Lines {
+			lines = append(lines, models.PurchaseOrderLine{
+				ProductID: l.ProductID,
+				Quantity:  l.OrderQuantity,
+				UnitID:    l.UnitID,
+				Price:     l.Price,
+			})
+		}
+		if err := insertPurchaseLines(tx, int(id), *group.SupplierID, lines); err != nil {
+			respondWithStockError(w, err)
+			return
+		}
+
+		po, err := loadPurchaseOrder(tx, int(id))
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		orders = append(orders, po)
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, orders)
+}
@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// respondWithStockError переводит ошибки складских операций в HTTP-ответ
+func respondWithStockError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInsufficientStock),
+		errors.Is(err, services.ErrInsufficientLotStock),
+		errors.Is(err, services.ErrLotBlocked),
+		errors.Is(err, services.ErrInsufficientLocationStock),
+		errors.Is(err, services.ErrInsufficientAvailableStock),
+		errors.Is(err, services.ErrReservationNotActive):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrProductNotFound),
+		errors.Is(err, services.ErrUnitNotFound),
+		errors.Is(err, services.ErrIncompatibleUnit),
+		errors.Is(err, services.ErrLotNotFound),
+		errors.Is(err, services.ErrPackageNotFound),
+		errors.Is(err, services.ErrLocationNotFound),
+		errors.Is(err, services.ErrInvalidQuantity):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrReservationNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// GetStockMovements возвращает журнал движения товаров (?product_id= — по одному продукту)
+func GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT m.id, m.product_id, p.name, m.movement_type, m.quantity, m.unit_cost, m.lot_id,
+			m.reference_type, m.reference_id, m.note, m.created_by, m.created_at
+		FROM stock_movements m
+		JOIN products p ON m.product_id = p.id
+	`
+	var args []interface{}
+	if value := r.URL.Query().Get("product_id"); value != "" {
+		productID, err := strconv.Atoi(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+			return
+		}
+		query += " WHERE m.product_id = ?"
+		args = append(args, productID)
+	}
+	query += " ORDER BY m.created_at DESC, m.id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	movements := []models.StockMovement{}
+	for rows.Next() {
+		var m models.StockMovement
+		err := rows.Scan(&m.ID, &m.ProductID, &m.ProductName, &m.MovementType, &m.Quantity, &m.UnitCost, &m.LotID,
+			&m.ReferenceType, &m.ReferenceID, &m.Note, &m.CreatedBy, &m.CreatedAt)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		movements = append(movements, m)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, movements)
+}
+
+// ReceiveStock проводит поступление продукта вне заказа поставщику,
+// при необходимости — в партию с номером и сроком годности
+func ReceiveStock(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ProductID  int     `json:"product_id"`
+		Quantity   float64 `json:"quantity"`
+		UnitID     int     `json:"unit_id"`
+		PackageID  int     `json:"package_id"` // поступление в упаковках вместо unit_id
+		Price      float64 `json:"price"`      // цена за единицу (упаковку) поступления
+		LotNumber  string  `json:"lot_number"`
+		ExpiryDate *string `json:"expiry_date"`
+		LocationID int     `json:"location_id"`
+		Note       string  `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.ExpiryDate != nil && !isValidDate(*input.ExpiryDate) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid expiry date (expected YYYY-MM-DD)")
+		return
+	}
+	if input.Quantity <= 0 || input.Price < 0 {
+		respondWithStockError(w, services.ErrInvalidQuantity)
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	baseQuantity, err := packageInputToBase(tx, input.ProductID, input.PackageID, input.UnitID, input.Quantity)
+	if err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	lotID, err := services.FindOrCreateLot(tx, input.ProductID, input.LotNumber, input.ExpiryDate)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	movement := models.StockMovement{
+		ProductID:    input.ProductID,
+		MovementType: models.MovementReceipt,
+		Quantity:     baseQuantity,
+		UnitCost:     input.Price * input.Quantity / float64(baseQuantity),
+		LotID:        lotID,
+		LocationID:   input.LocationID,
+		Note:         input.Note,
+		CreatedBy:    currentUsername(r),
+	}
+	if err := services.PostMovement(tx, &movement); err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, movement)
+}
+
+// IssueStock проводит расход продукта (из места хранения, если оно указано). Партии расходуются по правилу FEFO;
+// поле lots позволяет вручную указать партии, которые нужно списать в первую очередь.
+func IssueStock(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ProductID  int     `json:"product_id"`
+		Quantity   float64 `json:"quantity"`
+		UnitID     int     `json:"unit_id"`
+		LocationID int     `json:"location_id"`
+		Note       string  `json:"note"`
+		Lots       []struct {
+			LotID    int     `json:"lot_id"`
+			Quantity float64 `json:"quantity"` // в единице расхода
+		} `json:"lots"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	baseQuantity, err := inputToBase(tx, input.ProductID, input.UnitID, input.Quantity)
+	if err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	// Зарезервированный товар списывается только исполнением резерва
+	available, err := services.AvailableQuantity(tx, input.ProductID)
+	if err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+	if available < baseQuantity {
+		respondWithStockError(w, services.ErrInsufficientAvailableStock)
+		return
+	}
+
+	var allocations []models.LotAllocation
+	for _, l := range input.Lots {
+		quantity, err := inputToBase(tx, input.ProductID, input.UnitID, l.Quantity)
+		if err != nil {
+			respondWithStockError(w, err)
+			return
+		}
+		allocations = append(allocations, models.LotAllocation{LotID: l.LotID, Quantity: quantity})
+	}
+
+	movements, err := services.IssueStock(tx, models.StockMovement{
+		ProductID:    input.ProductID,
+		MovementType: models.MovementIssue,
+		Quantity:     baseQuantity,
+		LocationID:   input.LocationID,
+		Note:         input.Note,
+		CreatedBy:    currentUsername(r),
+	}, allocations)
+	if err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, movements)
+}
+
+// inputToBase переводит положительное количество из запроса в целые базовые единицы продукта.
+// Если единица не указана, используется единица продукта.
+func inputToBase(q services.Querier, productID, unitID int, quantity float64) (int, error) {
+	if quantity <= 0 {
+		return 0, services.ErrInvalidQuantity
+	}
+	if unitID == 0 {
+		err := q.QueryRow("SELECT unit_id FROM products WHERE id = ?", productID).Scan(&unitID)
+		if err == sql.ErrNoRows {
+			return 0, services.ErrProductNotFound
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	base, err := services.ConvertToBase(q, productID, unitID, quantity)
+	if err != nil {
+		return 0, err
+	}
+	if math.Round(base) <= 0 {
+		return 0, services.ErrInvalidQuantity
+	}
+	return int(math.Round(base)), nil
+}
+
+// packageInputToBase переводит количество из запроса в базовые единицы: в упаковках
+// packageID, если она указана, иначе — в единице unitID
+func packageInputToBase(q services.Querier, productID, packageID, unitID int, quantity float64) (int, error) {
+	if packageID != 0 {
+		return services.PackageToBase(q, productID, packageID, quantity)
+	}
+	return inputToBase(q, productID, unitID, quantity)
+}
+
+// TransferStock атомарно перемещает продукт между местами хранения.
+// Нулевое место хранения означает нераспределённый остаток.
+func TransferStock(w http.ResponseWriter, r *http.Request) {
+	var t models.StockTransfer
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if t.FromLocationID == t.ToLocationID {
+		utils.RespondWithError(w, http.StatusBadRequest, "Source and destination locations must differ")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if t.UnitID == 0 {
+		err := tx.QueryRow("SELECT unit_id FROM products WHERE id = ?", t.ProductID).Scan(&t.UnitID)
+		if err == sql.ErrNoRows {
+			respondWithStockError(w, services.ErrProductNotFound)
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+	t.BaseQuantity, err = inputToBase(tx, t.ProductID, t.UnitID, t.Quantity)
+	if err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+	t.CreatedBy = currentUsername(r)
+
+	if err := services.TransferStock(tx, &t); err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, t)
+}
@@ -0,0 +1,588 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/middleware"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// applyStocktakeCostVisibility скрывает стоимость расхождений от пользователей без роли admin или manager
+func applyStocktakeCostVisibility(r *http.Request, st *models.Stocktake) {
+	if middleware.HasRole(r, "admin", "manager") {
+		return
+	}
+	st.VarianceValue = nil
+	for i := range st.Lines {
+		st.Lines[i].UnitCost = nil
+		st.Lines[i].VarianceValue = nil
+	}
+}
+
+// stocktakeLineMoved — изменение остатка продукта строки l инвентаризации st после снимка
+// (движения в месте хранения инвентаризации). При закрытии значение фиксируется, чтобы
+// корректировки и последующие движения его не меняли.
+const stocktakeLineMoved = `
+	COALESCE(l.moved_quantity, (
+		SELECT SUM(m.quantity) FROM stock_movements m
+		WHERE m.id > st.snapshot_movement_id AND m.product_id = l.product_id
+			AND m.location_id = COALESCE(st.location_id, 0)
+	), 0)
+`
+
+// stocktakeVarianceValue — стоимость расхождений по подсчитанным продуктам инвентаризации st
+const stocktakeVarianceValue = `
+	COALESCE((
+		SELECT SUM(c.quantity * l.unit_cost) FROM stocktake_counts c
+		JOIN stocktake_lines l ON l.stocktake_id = c.stocktake_id AND l.product_id = c.product_id
+		WHERE c.stocktake_id = st.id
+	), 0) - COALESCE((
+		SELECT SUM((l.expected_quantity + ` + stocktakeLineMoved + `) * l.unit_cost) FROM stocktake_lines l
+		WHERE l.stocktake_id = st.id AND EXISTS (
+			SELECT 1 FROM stocktake_counts c WHERE c.stocktake_id = l.stocktake_id AND c.product_id = l.product_id
+		)
+	), 0)
+`
+
+func loadStocktake(q services.Querier, id int) (models.Stocktake, error) {
+	var st models.Stocktake
+	err := q.QueryRow(`
+		SELECT st.id, st.name, st.location_id, st.status, st.created_by, st.created_at,
+			st.approved_by, st.approved_at, st.closed_by, st.closed_at
+		FROM stocktakes st
+		WHERE st.id = ?
+	`, id).Scan(&st.ID, &st.Name, &st.LocationID, &st.Status, &st.CreatedBy, &st.CreatedAt,
+		&st.ApprovedBy, &st.ApprovedAt, &st.ClosedBy, &st.ClosedAt)
+	if err != nil {
+		return st, err
+	}
+
+	rows, err := q.Query(`
+		SELECT l.product_id, p.name, l.expected_quantity, `+stocktakeLineMoved+`, l.unit_cost, l.movement_id
+		FROM stocktake_lines l
+		JOIN stocktakes st ON l.stocktake_id = st.id
+		JOIN products p ON l.product_id = p.id
+		WHERE l.stocktake_id = ?
+		ORDER BY p.name, l.product_id
+	`, id)
+	if err != nil {
+		return st, err
+	}
+	lines := make(map[int]int)
+	for rows.Next() {
+		var l models.StocktakeLine
+		var unitCost float64
+		if err := rows.Scan(&l.ProductID, &l.ProductName, &l.ExpectedQuantity, &l.MovedQuantity, &unitCost, &l.MovementID); err != nil {
+			rows.Close()
+			return st, err
+		}
+		l.UnitCost = &unitCost
+		lines[l.ProductID] = len(st.Lines)
+		st.Lines = append(st.Lines, l)
+	}
+	rows.Close()
+
+	rows, err = q.Query(`
+		SELECT id, product_id, quantity, counted_by, counted_at
+		FROM stocktake_counts
+		WHERE stocktake_id = ?
+		ORDER BY id
+	`, id)
+	if err != nil {
+		return st, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c models.StocktakeCount
+		if err := rows.Scan(&c.ID, &c.ProductID, &c.Quantity, &c.CountedBy, &c.CountedAt); err != nil {
+			return st, err
+		}
+		l := &st.Lines[lines[c.ProductID]]
+		if l.CountedQuantity == nil {
+			l.CountedQuantity = new(int)
+		}
+		*l.CountedQuantity += c.Quantity
+		l.Counts = append(l.Counts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return st, err
+	}
+
+	var total float64
+	for i := range st.Lines {
+		l := &st.Lines[i]
+		if l.CountedQuantity == nil {
+			continue
+		}
+		// Движения во время подсчёта уже отражены в подсчитанном количестве
+		variance := *l.CountedQuantity - (l.ExpectedQuantity + l.MovedQuantity)
+		l.Variance = &variance
+		value := utils.RoundMoney(float64(variance) * *l.UnitCost)
+		l.VarianceValue = &value
+		total += value
+	}
+	total = utils.RoundMoney(total)
+	st.VarianceValue = &total
+
+	return st, nil
+}
+
+// GetStocktakes возвращает список инвентаризаций (?status= — фильтр по состоянию)
+func GetStocktakes(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT st.id, st.name, st.location_id, st.status, st.created_by, st.created_at,
+			st.approved_by, st.approved_at, st.closed_by, st.closed_at,` + stocktakeVarianceValue + `
+		FROM stocktakes st
+	`
+	var args []interface{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		query += " WHERE st.status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY st.created_at DESC, st.id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	stocktakes := []models.Stocktake{}
+	for rows.Next() {
+		var st models.Stocktake
+		var value float64
+		err := rows.Scan(&st.ID, &st.Name, &st.LocationID, &st.Status, &st.CreatedBy, &st.CreatedAt,
+			&st.ApprovedBy, &st.ApprovedAt, &st.ClosedBy, &st.ClosedAt, &value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		value = utils.RoundMoney(value)
+		st.VarianceValue = &value
+		applyStocktakeCostVisibility(r, &st)
+		stocktakes = append(stocktakes, st)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, stocktakes)
+}
+
+// GetStocktake возвращает инвентаризацию с расхождениями и подсчётами по продуктам
+func GetStocktake(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid stocktake ID")
+		return
+	}
+
+	st, err := loadStocktake(database.DB, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Stocktake not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	applyStocktakeCostVisibility(r, &st)
+	utils.RespondWithJSON(w, http.StatusOK, st)
+}
+
+// CreateStocktake открывает инвентаризацию и фиксирует ожидаемые остатки.
+// Без списка продуктов в инвентаризацию попадают все продукты места хранения
+// (или все продукты, если место хранения не указано).
+func CreateStocktake(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		Name       string `json:"name"`
+		LocationID *int   `json:"location_id"`
+		ProductIDs []int  `json:"product_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.LocationID != nil && *input.LocationID == 0 {
+		input.LocationID = nil
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if input.LocationID != nil {
+		var locations int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM locations WHERE id = ?", *input.LocationID).Scan(&locations); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if locations == 0 {
+			respondWithStockError(w, services.ErrLocationNotFound)
+			return
+		}
+	}
+
+	productIDs := input.ProductIDs
+	if len(productIDs) == 0 {
+		query := "SELECT id FROM products ORDER BY id"
+		var args []interface{}
+		if input.LocationID != nil {
+			query = "SELECT product_id FROM location_stock WHERE location_id = ? ORDER BY product_id"
+			args = append(args, *input.LocationID)
+		}
+		rows, err := tx.Query(query, args...)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		for rows.Next() {
+			var productID int
+			if err := rows.Scan(&productID); err != nil {
+				rows.Close()
+				utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+				return
+			}
+			productIDs = append(productIDs, productID)
+		}
+		rows.Close()
+	}
+	if len(productIDs) == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "No products to count")
+		return
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO stocktakes (name, location_id, status, created_by, snapshot_movement_id)
+		VALUES (?, ?, ?, ?, (SELECT COALESCE(MAX(id), 0) FROM stock_movements))
+	`, input.Name, input.LocationID, models.StocktakeOpen, currentUsername(r))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	seen := make(map[int]bool, len(productIDs))
+	for _, productID := range productIDs {
+		if seen[productID] {
+			continue
+		}
+		seen[productID] = true
+
+		// Себестоимость базовой единицы на момент открытия инвентаризации
+		var unitCost float64
+		err := tx.QueryRow(`
+			SELECT CASE WHEN u.factor > 0 THEN p.cost_price / u.factor ELSE 0 END
+			FROM products p JOIN units u ON p.unit_id = u.id
+			WHERE p.id = ?
+		`, productID).Scan(&unitCost)
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusBadRequest, "Product "+strconv.Itoa(productID)+" not found")
+			return
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		var expected int
+		if input.LocationID != nil {
+			err = tx.QueryRow(`
+				SELECT COALESCE((SELECT quantity FROM location_stock WHERE location_id = ? AND product_id = ?), 0)
+			`, *input.LocationID, productID).Scan(&expected)
+		} else {
+			expected, err = services.UnassignedQuantity(tx, productID)
+		}
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO stocktake_lines (stocktake_id, product_id, expected_quantity, unit_cost) VALUES (?, ?, ?, ?)
+		`, id, productID, expected, unitCost)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	st, err := loadStocktake(tx, int(id))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	applyStocktakeCostVisibility(r, &st)
+	utils.RespondWithJSON(w, http.StatusCreated, st)
+}
+
+// AddStocktakeCount добавляет подсчёт продукта. Подсчёты разных пользователей
+// (например, по разным полкам) суммируются.
+func AddStocktakeCount(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid stocktake ID")
+		return
+	}
+
+	var input struct {
+		ProductID int     `json:"product_id"`
+		Quantity  float64 `json:"quantity"`
+		UnitID    int     `json:"unit_id"`
+		PackageID int     `json:"package_id"` // подсчёт в упаковках вместо unit_id
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.Quantity < 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Quantity must not be negative")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM stocktakes WHERE id = ?", id).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Stocktake not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if status != models.StocktakeOpen {
+		utils.RespondWithError(w, http.StatusConflict, "Counts can only be added to an open stocktake")
+		return
+	}
+
+	var lines int
+	err = tx.QueryRow("SELECT COUNT(*) FROM stocktake_lines WHERE stocktake_id = ? AND product_id = ?", id, input.ProductID).Scan(&lines)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if lines == 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Product is not part of the stocktake")
+		return
+	}
+
+	// Нулевой подсчёт означает, что продукта на месте нет
+	quantity := 0
+	if input.Quantity > 0 {
+		quantity, err = packageInputToBase(tx, input.ProductID, input.PackageID, input.UnitID, input.Quantity)
+		if err != nil {
+			respondWithStockError(w, err)
+			return
+		}
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO stocktake_counts (stocktake_id, product_id, quantity, counted_by) VALUES (?, ?, ?, ?)
+	`, id, input.ProductID, quantity, currentUsername(r))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	st, err := loadStocktake(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	applyStocktakeCostVisibility(r, &st)
+	utils.RespondWithJSON(w, http.StatusCreated, st)
+}
+
+// ApproveStocktake утверждает расхождения; после утверждения подсчёты не принимаются
+func ApproveStocktake(w http.ResponseWriter, r *http.Request) {
+	changeStocktakeStatus(w, r, []string{models.StocktakeOpen}, models.StocktakeApproved,
+		"UPDATE stocktakes SET status = ?, approved_by = ?, approved_at = CURRENT_TIMESTAMP WHERE id = ?")
+}
+
+// CancelStocktake отменяет инвентаризацию без изменения остатков
+func CancelStocktake(w http.ResponseWriter, r *http.Request) {
+	changeStocktakeStatus(w, r, []string{models.StocktakeOpen, models.StocktakeApproved}, models.StocktakeCancelled,
+		"UPDATE stocktakes SET status = ?, closed_by = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ?")
+}
+
+func changeStocktakeStatus(w http.ResponseWriter, r *http.Request, from []string, to string, update string) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid stocktake ID")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM stocktakes WHERE id = ?", id).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Stocktake not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	allowed := false
+	for _, s := range from {
+		if s == status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		utils.RespondWithError(w, http.StatusConflict, "Cannot change stocktake status from "+status+" to "+to)
+		return
+	}
+
+	if _, err := tx.Exec(update, to, currentUsername(r), id); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	st, err := loadStocktake(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	applyStocktakeCostVisibility(r, &st)
+	utils.RespondWithJSON(w, http.StatusOK, st)
+}
+
+// CloseStocktake проводит утверждённые расхождения корректировками остатков.
+// Расхождение считается от снимка с учётом движений после него, поэтому остаток
+// становится равным подсчитанному. Продукты без подсчётов не корректируются.
+func CloseStocktake(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid stocktake ID")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	st, err := loadStocktake(tx, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Stocktake not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if st.Status != models.StocktakeApproved {
+		utils.RespondWithError(w, http.StatusConflict, "Only approved stocktakes can be closed")
+		return
+	}
+
+	locationID := 0
+	if st.LocationID != nil {
+		locationID = *st.LocationID
+	}
+	username := currentUsername(r)
+	for _, l := range st.Lines {
+		_, err = tx.Exec("UPDATE stocktake_lines SET moved_quantity = ? WHERE stocktake_id = ? AND product_id = ?",
+			l.MovedQuantity, id, l.ProductID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if l.Variance == nil || *l.Variance == 0 {
+			continue
+		}
+
+		movement := models.StockMovement{
+			ProductID:     l.ProductID,
+			MovementType:  models.MovementAdjustment,
+			Quantity:      *l.Variance,
+			UnitCost:      *l.UnitCost,
+			LocationID:    locationID,
+			ReferenceType: "stocktake",
+			ReferenceID:   id,
+			Note:          "Инвентаризация",
+			CreatedBy:     username,
+		}
+		if err := services.PostMovement(tx, &movement); err != nil {
+			respondWithStockError(w, err)
+			return
+		}
+
+		_, err = tx.Exec("UPDATE stocktake_lines SET movement_id = ? WHERE stocktake_id = ? AND product_id = ?",
+			movement.ID, id, l.ProductID)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE stocktakes SET status = ?, closed_by = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, models.StocktakeClosed, username, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	st, err = loadStocktake(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	applyStocktakeCostVisibility(r, &st)
+	utils.RespondWithJSON(w, http.StatusOK, st)
+}
@@ -0,0 +1,294 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// GetSuppliers возвращает список всех поставщиков
+func GetSuppliers(w http.ResponseWriter, r *http.Request) {
+	rows, err := database.DB.Query(`
+		SELECT id, name, contact_person, phone, email, address, lead_time_days
+		FROM suppliers ORDER BY name
+	`)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	suppliers := []models.Supplier{}
+	for rows.Next() {
+		var s models.Supplier
+		err := rows.Scan(&s.ID, &s.Name, &s.ContactPerson, &s.Phone, &s.Email, &s.Address, &s.LeadTimeDays)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		suppliers = append(suppliers, s)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, suppliers)
+}
+
+// GetSupplier возвращает поставщика вместе с условиями поставки продуктов
+func GetSupplier(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid supplier ID")
+		return
+	}
+
+	var s models.Supplier
+	err = database.DB.QueryRow(`
+		SELECT id, name, contact_person, phone, email, address, lead_time_days
+		FROM suppliers WHERE id = ?
+	`, id).Scan(&s.ID, &s.Name, &s.ContactPerson, &s.Phone, &s.Email, &s.Address, &s.LeadTimeDays)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusNotFound, "Supplier not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+
+	rows, err := database.DB.Query(`
+		SELECT sp.supplier_id, sp.product_id, p.name, sp.supplier_sku, sp.last_price, sp.unit_id
+		FROM supplier_products sp
+		JOIN products p ON sp.product_id = p.id
+		WHERE sp.supplier_id = ?
+		ORDER BY p.name
+	`, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var sp models.SupplierProduct
+		if err := rows.Scan(&sp.SupplierID, &sp.ProductID, &sp.ProductName, &sp.SupplierSKU, &sp.LastPrice, &sp.UnitID); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		s.Products = append(s.Products, sp)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, s)
+}
+
+// CreateSupplier создает нового поставщика
+func CreateSupplier(w http.ResponseWriter, r *http.Request) {
+	var s models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if s.Name == "" || s.LeadTimeDays < 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Supplier name is required and lead time must not be negative")
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO suppliers (name, contact_person, phone, email, address, lead_time_days)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, s.Name, s.ContactPerson, s.Phone, s.Email, s.Address, s.LeadTimeDays)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusConflict, "Supplier already exists")
+		return
+	}
+
+	id, _ := result.LastInsertId()
+	s.ID = int(id)
+	s.Products = nil
+
+	utils.RespondWithJSON(w, http.StatusCreated, s)
+}
+
+// UpdateSupplier обновляет контактные данные и срок поставки поставщика
+func UpdateSupplier(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid supplier ID")
+		return
+	}
+
+	var s models.Supplier
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if s.Name == "" || s.LeadTimeDays < 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Supplier name is required and lead time must not be negative")
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE suppliers
+		SET name = ?, contact_person = ?, phone = ?, email = ?, address = ?, lead_time_days = ?
+		WHERE id = ?
+	`, s.Name, s.ContactPerson, s.Phone, s.Email, s.Address, s.LeadTimeDays, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Supplier not found")
+		return
+	}
+
+	s.ID = id
+	s.Products = nil
+	utils.RespondWithJSON(w, http.StatusOK, s)
+}
+
+// DeleteSupplier удаляет поставщика, если по нему нет заказов
+func DeleteSupplier(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid supplier ID")
+		return
+	}
+
+	var orders int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM purchase_orders WHERE supplier_id = ?", id).Scan(&orders); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if orders > 0 {
+		utils.RespondWithError(w, http.StatusConflict, "Supplier has purchase orders")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM supplier_products WHERE supplier_id = ?", id); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	result, err := tx.Exec("DELETE FROM suppliers WHERE id = ?", id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Supplier not found")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Supplier deleted successfully"})
+}
+
+// SetSupplierProduct добавляет или обновляет артикул и цену продукта у поставщика
+func SetSupplierProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid supplier ID")
+		return
+	}
+
+	var sp models.SupplierProduct
+	if err := json.NewDecoder(r.Body).Decode(&sp); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	sp.SupplierID = id
+	if sp.LastPrice < 0 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Price must not be negative")
+		return
+	}
+
+	var suppliers int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM suppliers WHERE id = ?", id).Scan(&suppliers); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if suppliers == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Supplier not found")
+		return
+	}
+
+	// Цена по умолчанию указывается за единицу измерения продукта
+	var productUnitID int
+	err = database.DB.QueryRow("SELECT unit_id FROM products WHERE id = ?", sp.ProductID).Scan(&productUnitID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			utils.RespondWithError(w, http.StatusBadRequest, "Product not found")
+		} else {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		}
+		return
+	}
+	if sp.UnitID == 0 {
+		sp.UnitID = productUnitID
+	}
+	if _, err := services.ConvertToBase(database.DB, sp.ProductID, sp.UnitID, 1); err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	_, err = database.DB.Exec(`
+		INSERT INTO supplier_products (supplier_id, product_id, supplier_sku, last_price, unit_id)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (supplier_id, product_id) DO UPDATE SET
+			supplier_sku = excluded.supplier_sku,
+			last_price = excluded.last_price,
+			unit_id = excluded.unit_id
+	`, sp.SupplierID, sp.ProductID, sp.SupplierSKU, sp.LastPrice, sp.UnitID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, sp)
+}
+
+// DeleteSupplierProduct удаляет условия поставки продукта поставщиком
+func DeleteSupplierProduct(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid supplier ID")
+		return
+	}
+	productID, err := strconv.Atoi(vars["product_id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM supplier_products WHERE supplier_id = ? AND product_id = ?", id, productID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Supplier product not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Supplier product deleted successfully"})
+}
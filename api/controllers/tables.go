@@ -0,0 +1,232 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/mattn/go-sqlite3"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// isValidTableStatus проверяет состояние столика
+func isValidTableStatus(status string) bool {
+	switch status {
+	case models.TableFree, models.TableOccupied, models.TableReserved, models.TableOutOfService:
+		return true
+	}
+	return false
+}
+
+// respondWithTableError переводит ошибки сохранения столика в HTTP-ответ
+func respondWithTableError(w http.ResponseWriter, err error) {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+		utils.RespondWithError(w, http.StatusConflict, "Table with this name already exists")
+		return
+	}
+	utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+}
+
+// GetTables возвращает столики с их незакрытыми заказами (?status= — фильтр по состоянию)
+func GetTables(w http.ResponseWriter, r *http.Request) {
+	rows, err := database.DB.Query(`
+		SELECT id, table_id FROM orders
+		WHERE table_id IS NOT NULL AND status IN (?, ?, ?)
+		ORDER BY id
+	`, models.OrderOpen, models.OrderSentToKitchen, models.OrderServed)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	openOrders := make(map[int][]int)
+	for rows.Next() {
+		var orderID, tableID int
+		if err := rows.Scan(&orderID, &tableID); err != nil {
+			rows.Close()
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		openOrders[tableID] = append(openOrders[tableID], orderID)
+	}
+	rows.Close()
+
+	query := "SELECT id, name, capacity, status FROM tables"
+	var args []interface{}
+	if status := r.URL.Query().Get("status"); status != "" {
+		query += " WHERE status = ?"
+		args = append(args, status)
+	}
+	query += " ORDER BY name"
+
+	rows, err = database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	tables := []models.DiningTable{}
+	for rows.Next() {
+		var t models.DiningTable
+		if err := rows.Scan(&t.ID, &t.Name, &t.Capacity, &t.Status); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		t.OpenOrders = openOrders[t.ID]
+		tables = append(tables, t)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, tables)
+}
+
+// CreateTable добавляет столик
+func CreateTable(w http.ResponseWriter, r *http.Request) {
+	var t models.DiningTable
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if t.Status == "" {
+		t.Status = models.TableFree
+	}
+	if t.Name == "" || t.Capacity < 1 || !isValidTableStatus(t.Status) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Table name, positive capacity and valid status are required")
+		return
+	}
+
+	result, err := database.DB.Exec("INSERT INTO tables (name, capacity, status) VALUES (?, ?, ?)", t.Name, t.Capacity, t.Status)
+	if err != nil {
+		respondWithTableError(w, err)
+		return
+	}
+	id, _ := result.LastInsertId()
+	t.ID = int(id)
+	t.OpenOrders = nil
+
+	utils.RespondWithJSON(w, http.StatusCreated, t)
+}
+
+// UpdateTable изменяет название и вместимость столика
+func UpdateTable(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid table ID")
+		return
+	}
+
+	var t models.DiningTable
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if t.Name == "" || t.Capacity < 1 {
+		utils.RespondWithError(w, http.StatusBadRequest, "Table name and positive capacity are required")
+		return
+	}
+
+	result, err := database.DB.Exec("UPDATE tables SET name = ?, capacity = ? WHERE id = ?", t.Name, t.Capacity, id)
+	if err != nil {
+		respondWithTableError(w, err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Table not found")
+		return
+	}
+
+	if err := database.DB.QueryRow("SELECT status FROM tables WHERE id = ?", id).Scan(&t.Status); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	t.ID = id
+	t.OpenOrders = nil
+	utils.RespondWithJSON(w, http.StatusOK, t)
+}
+
+// SetTableStatus меняет состояние столика вручную (бронь, вывод из работы).
+// Столик с незакрытыми заказами нельзя освободить или вывести из работы.
+func SetTableStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid table ID")
+		return
+	}
+
+	var input struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if !isValidTableStatus(input.Status) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid table status")
+		return
+	}
+
+	if input.Status != models.TableOccupied {
+		var orders int
+		err := database.DB.QueryRow(`
+			SELECT COUNT(*) FROM orders WHERE table_id = ? AND status IN (?, ?, ?)
+		`, id, models.OrderOpen, models.OrderSentToKitchen, models.OrderServed).Scan(&orders)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if orders > 0 {
+			utils.RespondWithError(w, http.StatusConflict, "Table has open orders")
+			return
+		}
+	}
+
+	result, err := database.DB.Exec("UPDATE tables SET status = ? WHERE id = ?", input.Status, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Table not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Table status updated"})
+}
+
+// DeleteTable удаляет столик, за которым ещё не было заказов
+func DeleteTable(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid table ID")
+		return
+	}
+
+	var orders int
+	if err := database.DB.QueryRow("SELECT COUNT(*) FROM orders WHERE table_id = ?", id).Scan(&orders); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if orders > 0 {
+		utils.RespondWithError(w, http.StatusConflict, "Table has orders")
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM tables WHERE id = ?", id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		utils.RespondWithError(w, http.StatusNotFound, "Table not found")
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Table deleted successfully"})
+}
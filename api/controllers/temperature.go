@@ -0,0 +1,432 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/mattn/go-sqlite3"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// respondWithTemperatureError переводит ошибки журнала температуры в HTTP-ответ
+func respondWithTemperatureError(w http.ResponseWriter, err error) {
+	var sqliteErr sqlite3.Error
+	switch {
+	case errors.Is(err, services.ErrStorageUnitNotFound), errors.Is(err, services.ErrCorrectiveActionNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrStorageUnitInactive), errors.Is(err, services.ErrCorrectiveActionDone):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	case errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique:
+		utils.RespondWithError(w, http.StatusConflict, "Storage unit with this name already exists")
+	default:
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// validateStorageUnit проверяет поля места хранения; возвращает текст ошибки или пустую строку
+func validateStorageUnit(q services.Querier, u *models.StorageUnit) string {
+	u.Name = strings.TrimSpace(u.Name)
+	if u.Name == "" {
+		return "Storage unit name is required"
+	}
+	if !services.IsValidStorageType(u.UnitType) {
+		return services.ErrInvalidStorageType.Error()
+	}
+	if u.MinTemp > u.MaxTemp {
+		return services.ErrInvalidTemperatureRange.Error()
+	}
+	if u.LocationID != nil {
+		var exists int
+		if err := q.QueryRow("SELECT COUNT(*) FROM locations WHERE id = ?", *u.LocationID).Scan(&exists); err != nil {
+			return err.Error()
+		}
+		if exists == 0 {
+			return "Location not found"
+		}
+	}
+	return ""
+}
+
+// GetStorageUnits возвращает оборудование для хранения с последними показаниями
+// (?active=true — только используемое)
+func GetStorageUnits(w http.ResponseWriter, r *http.Request) {
+	query := "SELECT id FROM storage_units"
+	if r.URL.Query().Get("active") == "true" {
+		query += " WHERE active = 1"
+	}
+	query += " ORDER BY name"
+
+	rows, err := database.DB.Query(query)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	units := []models.StorageUnit{}
+	for _, id := range ids {
+		u, err := services.LoadStorageUnit(database.DB, id)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		units = append(units, u)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, units)
+}
+
+// GetStorageUnit возвращает место хранения с последним показанием
+func GetStorageUnit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid storage unit ID")
+		return
+	}
+
+	u, err := services.LoadStorageUnit(database.DB, id)
+	if err != nil {
+		respondWithTemperatureError(w, err)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, u)
+}
+
+// CreateStorageUnit добавляет холодильник, морозильник или другое место хранения
+// с допустимым диапазоном температуры
+func CreateStorageUnit(w http.ResponseWriter, r *http.Request) {
+	u := models.StorageUnit{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if msg := validateStorageUnit(database.DB, &u); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		INSERT INTO storage_units (name, unit_type, location_id, min_temp, max_temp, active) VALUES (?, ?, ?, ?, ?, ?)
+	`, u.Name, u.UnitType, u.LocationID, u.MinTemp, u.MaxTemp, u.Active)
+	if err != nil {
+		respondWithTemperatureError(w, err)
+		return
+	}
+	id, _ := result.LastInsertId()
+
+	u, err = services.LoadStorageUnit(database.DB, int(id))
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, u)
+}
+
+// UpdateStorageUnit изменяет место хранения. Новый диапазон применяется к следующим
+// показаниям; нарушения хранят диапазон, действовавший на момент показания.
+func UpdateStorageUnit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid storage unit ID")
+		return
+	}
+
+	u := models.StorageUnit{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if msg := validateStorageUnit(database.DB, &u); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := database.DB.Exec(`
+		UPDATE storage_units SET name = ?, unit_type = ?, location_id = ?, min_temp = ?, max_temp = ?, active = ?
+		WHERE id = ?
+	`, u.Name, u.UnitType, u.LocationID, u.MinTemp, u.MaxTemp, u.Active, id)
+	if err != nil {
+		respondWithTemperatureError(w, err)
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		respondWithTemperatureError(w, services.ErrStorageUnitNotFound)
+		return
+	}
+
+	u, err = services.LoadStorageUnit(database.DB, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, u)
+}
+
+// DeleteStorageUnit удаляет место хранения без записей в журнале. Журнал нужен
+// проверяющим, поэтому использованное оборудование только выводится из работы (active=false).
+func DeleteStorageUnit(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid storage unit ID")
+		return
+	}
+
+	var readings int
+	err = database.DB.QueryRow("SELECT COUNT(*) FROM temperature_readings WHERE storage_unit_id = ?", id).Scan(&readings)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if readings > 0 {
+		utils.RespondWithError(w, http.StatusConflict, "Storage unit has temperature records; deactivate it instead")
+		return
+	}
+
+	result, err := database.DB.Exec("DELETE FROM storage_units WHERE id = ?", id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		respondWithTemperatureError(w, services.ErrStorageUnitNotFound)
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Storage unit deleted successfully"})
+}
+
+// GetTemperatureReadings возвращает журнал температуры за период (?from=&to=, по умолчанию —
+// с начала месяца); ?storage_unit_id= — по одному месту хранения, ?out_of_range=true — только нарушения
+func GetTemperatureReadings(w http.ResponseWriter, r *http.Request) {
+	from, to, err := reportPeriod(r)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := `
+		SELECT r.id, r.storage_unit_id, s.name, r.temperature, r.humidity, r.in_range, r.note, r.recorded_by, r.recorded_at
+		FROM temperature_readings r
+		JOIN storage_units s ON r.storage_unit_id = s.id
+		WHERE r.recorded_at >= ? AND r.recorded_at <= ?
+	`
+	args := []interface{}{utils.FormatDBTime(from), utils.FormatDBTime(to)}
+	if value := r.URL.Query().Get("storage_unit_id"); value != "" {
+		unitID, err := strconv.Atoi(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid storage unit ID")
+			return
+		}
+		query += " AND r.storage_unit_id = ?"
+		args = append(args, unitID)
+	}
+	if r.URL.Query().Get("out_of_range") == "true" {
+		query += " AND r.in_range = 0"
+	}
+	query += " ORDER BY r.recorded_at DESC, r.id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer rows.Close()
+
+	readings := []models.TemperatureReading{}
+	for rows.Next() {
+		var t models.TemperatureReading
+		if err := rows.Scan(&t.ID, &t.StorageUnitID, &t.StorageUnitName, &t.Temperature, &t.Humidity, &t.InRange,
+			&t.Note, &t.RecordedBy, &t.RecordedAt); err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		readings = append(readings, t)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, readings)
+}
+
+// AddTemperatureReading записывает показание температуры от имени текущего пользователя.
+// recorded_at позволяет перенести показание с бумажного журнала (по умолчанию — сейчас).
+// Если показание открыло нарушение, оно возвращается вместе с записью.
+func AddTemperatureReading(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		StorageUnitID int      `json:"storage_unit_id"`
+		Temperature   *float64 `json:"temperature"`
+		Humidity      *float64 `json:"humidity"`
+		Note          string   `json:"note"`
+		RecordedAt    string   `json:"recorded_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if input.Temperature == nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Temperature is required")
+		return
+	}
+	if input.Humidity != nil && (*input.Humidity < 0 || *input.Humidity > 100) {
+		utils.RespondWithError(w, http.StatusBadRequest, "Humidity must be between 0 and 100")
+		return
+	}
+	recordedAt := time.Now()
+	if input.RecordedAt != "" {
+		t, err := utils.ParseDateTime(input.RecordedAt)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if t.After(recordedAt) {
+			utils.RespondWithError(w, http.StatusBadRequest, "recorded_at must not be in the future")
+			return
+		}
+		recordedAt = t
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	reading := models.TemperatureReading{
+		StorageUnitID: input.StorageUnitID,
+		Temperature:   *input.Temperature,
+		Humidity:      input.Humidity,
+		Note:          input.Note,
+		RecordedBy:    currentUsername(r),
+	}
+	alert, err := services.RecordTemperature(tx, &reading, recordedAt)
+	if err != nil {
+		respondWithTemperatureError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusCreated, struct {
+		Reading models.TemperatureReading `json:"reading"`
+		Alert   *models.TemperatureAlert  `json:"alert,omitempty"`
+	}{reading, alert})
+}
+
+// GetTemperatureAlerts возвращает нарушения температурного режима с корректирующими
+// действиями (?open=true — только не закрытые, ?storage_unit_id= — по одному месту хранения)
+func GetTemperatureAlerts(w http.ResponseWriter, r *http.Request) {
+	query := "SELECT id FROM temperature_alerts WHERE 1 = 1"
+	var args []interface{}
+	if r.URL.Query().Get("open") == "true" {
+		query += " AND resolved_at IS NULL"
+	}
+	if value := r.URL.Query().Get("storage_unit_id"); value != "" {
+		unitID, err := strconv.Atoi(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid storage unit ID")
+			return
+		}
+		query += " AND storage_unit_id = ?"
+		args = append(args, unitID)
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	alerts := []models.TemperatureAlert{}
+	for _, id := range ids {
+		a, err := services.LoadTemperatureAlert(database.DB, id)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		alerts = append(alerts, a)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, alerts)
+}
+
+// CompleteCorrectiveAction фиксирует, что было сделано по нарушению
+// (например, продукты перенесены в исправный холодильник)
+func CompleteCorrectiveAction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid corrective action ID")
+		return
+	}
+
+	var input struct {
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	input.Action = strings.TrimSpace(input.Action)
+	if input.Action == "" {
+		utils.RespondWithError(w, http.StatusBadRequest, "Action description is required")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	alert, err := services.CompleteCorrectiveAction(tx, id, input.Action, currentUsername(r))
+	if err != nil {
+		respondWithTemperatureError(w, err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, alert)
+}
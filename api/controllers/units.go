@@ -14,7 +14,7 @@ import (
 
 // GetUnits возвращает список всех единиц измерения
 func GetUnits(w http.ResponseWriter, r *http.Request) {
-	rows, err := database.DB.Query("SELECT id, name, abbreviation FROM units")
+	rows, err := database.DB.Query("SELECT id, name, abbreviation, dimension, factor FROM units")
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -24,7 +24,7 @@ func GetUnits(w http.ResponseWriter, r *http.Request) {
 	var units []models.Unit
 	for rows.Next() {
 		var u models.Unit
-		err := rows.Scan(&u.ID, &u.Name, &u.Abbreviation)
+		err := rows.Scan(&u.ID, &u.Name, &u.Abbreviation, &u.Dimension, &u.Factor)
 		if err != nil {
 			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 			return
@@ -45,7 +45,7 @@ func GetUnit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var u models.Unit
-	err = database.DB.QueryRow("SELECT id, name, abbreviation FROM units WHERE id = ?", id).Scan(&u.ID, &u.Name, &u.Abbreviation)
+	err = database.DB.QueryRow("SELECT id, name, abbreviation, dimension, factor FROM units WHERE id = ?", id).Scan(&u.ID, &u.Name, &u.Abbreviation, &u.Dimension, &u.Factor)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			utils.RespondWithError(w, http.StatusNotFound, "Unit not found")
@@ -66,6 +66,10 @@ func CreateUnit(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if msg := normalizeUnit(&u); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
 
 	// Начинаем транзакцию
 	tx, err := database.DB.Begin()
@@ -76,7 +80,7 @@ func CreateUnit(w http.ResponseWriter, r *http.Request) {
 	defer tx.Rollback()
 
 	// Вставляем новую единицу измерения
-	result, err := tx.Exec("INSERT INTO units (name, abbreviation) VALUES (?, ?)", u.Name, u.Abbreviation)
+	result, err := tx.Exec("INSERT INTO units (name, abbreviation, dimension, factor) VALUES (?, ?, ?, ?)", u.Name, u.Abbreviation, u.Dimension, u.Factor)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -111,6 +115,10 @@ func UpdateUnit(w http.ResponseWriter, r *http.Request) {
 		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	if msg := normalizeUnit(&u); msg != "" {
+		utils.RespondWithError(w, http.StatusBadRequest, msg)
+		return
+	}
 
 	// Начинаем транзакцию
 	tx, err := database.DB.Begin()
@@ -121,7 +129,7 @@ func UpdateUnit(w http.ResponseWriter, r *http.Request) {
 	defer tx.Rollback()
 
 	// Обновляем единицу измерения
-	_, err = tx.Exec("UPDATE units SET name = ?, abbreviation = ? WHERE id = ?", u.Name, u.Abbreviation, id)
+	_, err = tx.Exec("UPDATE units SET name = ?, abbreviation = ?, dimension = ?, factor = ? WHERE id = ?", u.Name, u.Abbreviation, u.Dimension, u.Factor, id)
 	if err != nil {
 		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
 		return
@@ -171,3 +179,22 @@ func DeleteUnit(w http.ResponseWriter, r *http.Request) {
 
 	utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Unit deleted successfully"})
 }
+
+// normalizeUnit подставляет размерность и множитель по умолчанию и проверяет их
+func normalizeUnit(u *models.Unit) string {
+	if u.Dimension == "" {
+		u.Dimension = models.DimensionCount
+	}
+	if u.Factor == 0 {
+		u.Factor = 1
+	}
+	switch u.Dimension {
+	case models.DimensionMass, models.DimensionVolume, models.DimensionCount:
+	default:
+		return "Unknown unit dimension"
+	}
+	if u.Factor < 0 {
+		return "Unit factor must be positive"
+	}
+	return ""
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/middleware"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/services"
+	"wuwunchik.github.io/api/utils"
+)
+
+// applyWriteOffCostVisibility скрывает стоимость списания от пользователей без роли admin или manager
+func applyWriteOffCostVisibility(r *http.Request, wo *models.WriteOff) {
+	if middleware.HasRole(r, "admin", "manager") {
+		return
+	}
+	wo.UnitCost = nil
+	wo.Cost = nil
+}
+
+// respondWithWriteOffError переводит ошибки списания в HTTP-ответ
+func respondWithWriteOffError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrWriteOffNotFound):
+		utils.RespondWithError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrWriteOffNotPending):
+		utils.RespondWithError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidWriteOffReason):
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondWithStockError(w, err)
+	}
+}
+
+// GetWriteOffs возвращает акты списания (?status=, ?reason= и ?product_id= — фильтры)
+func GetWriteOffs(w http.ResponseWriter, r *http.Request) {
+	query := "SELECT id FROM write_offs WHERE 1 = 1"
+	var args []interface{}
+	for _, filter := range []string{"status", "reason"} {
+		if value := r.URL.Query().Get(filter); value != "" {
+			query += " AND " + filter + " = ?"
+			args = append(args, value)
+		}
+	}
+	if value := r.URL.Query().Get("product_id"); value != "" {
+		productID, err := strconv.Atoi(value)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusBadRequest, "Invalid product ID")
+			return
+		}
+		query += " AND product_id = ?"
+		args = append(args, productID)
+	}
+	query += " ORDER BY created_at DESC, id DESC"
+
+	rows, err := database.DB.Query(query, args...)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	writeOffs := []models.WriteOff{}
+	for _, id := range ids {
+		wo, err := services.LoadWriteOff(database.DB, id)
+		if err != nil {
+			utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		applyWriteOffCostVisibility(r, &wo)
+		writeOffs = append(writeOffs, wo)
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, writeOffs)
+}
+
+// GetWriteOff возвращает акт списания
+func GetWriteOff(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid write-off ID")
+		return
+	}
+
+	wo, err := services.LoadWriteOff(database.DB, id)
+	if err != nil {
+		respondWithWriteOffError(w, err)
+		return
+	}
+	applyWriteOffCostVisibility(r, &wo)
+
+	utils.RespondWithJSON(w, http.StatusOK, wo)
+}
+
+// CreateWriteOff создаёт акт списания. Списание дороже порога WRITE_OFF_APPROVAL_THRESHOLD,
+// созданное не менеджером, ждёт утверждения; остальные проводятся сразу.
+func CreateWriteOff(w http.ResponseWriter, r *http.Request) {
+	var input struct {
+		ProductID  int     `json:"product_id"`
+		Quantity   float64 `json:"quantity"`
+		UnitID     int     `json:"unit_id"`
+		Reason     string  `json:"reason"`
+		LocationID *int    `json:"location_id"`
+		LotID      *int    `json:"lot_id"`
+		Note       string  `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	quantity, err := inputToBase(tx, input.ProductID, input.UnitID, input.Quantity)
+	if err != nil {
+		respondWithStockError(w, err)
+		return
+	}
+
+	wo := models.WriteOff{
+		ProductID:  input.ProductID,
+		Quantity:   quantity,
+		Reason:     input.Reason,
+		LocationID: input.LocationID,
+		LotID:      input.LotID,
+		Note:       input.Note,
+		CreatedBy:  currentUsername(r),
+	}
+	if err := services.CreateWriteOff(tx, &wo, middleware.HasRole(r, "admin", "manager")); err != nil {
+		respondWithWriteOffError(w, err)
+		return
+	}
+
+	wo, err = services.LoadWriteOff(tx, wo.ID)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	applyWriteOffCostVisibility(r, &wo)
+	status := http.StatusCreated
+	if wo.Status == models.WriteOffPending {
+		status = http.StatusAccepted
+	}
+	utils.RespondWithJSON(w, status, wo)
+}
+
+// ApproveWriteOff утверждает ожидающий акт и списывает товар
+func ApproveWriteOff(w http.ResponseWriter, r *http.Request) {
+	reviewWriteOff(w, r, services.ApproveWriteOff)
+}
+
+// RejectWriteOff отклоняет ожидающий акт списания
+func RejectWriteOff(w http.ResponseWriter, r *http.Request) {
+	reviewWriteOff(w, r, services.RejectWriteOff)
+}
+
+func reviewWriteOff(w http.ResponseWriter, r *http.Request, review func(*sql.Tx, int, string) error) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		utils.RespondWithError(w, http.StatusBadRequest, "Invalid write-off ID")
+		return
+	}
+
+	tx, err := database.DB.Begin()
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	defer tx.Rollback()
+
+	if err := review(tx, id, currentUsername(r)); err != nil {
+		respondWithWriteOffError(w, err)
+		return
+	}
+
+	wo, err := services.LoadWriteOff(tx, id)
+	if err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		utils.RespondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.RespondWithJSON(w, http.StatusOK, wo)
+}
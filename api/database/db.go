@@ -45,6 +45,15 @@ func createTables() error {
 		return err
 	}
 
+	// Размерность единицы и её множитель относительно базовой единицы размерности
+	err = addColumns("units", [][2]string{
+		{"dimension", "TEXT NOT NULL DEFAULT 'count'"},
+		{"factor", "REAL NOT NULL DEFAULT 1"},
+	})
+	if err != nil {
+		return err
+	}
+
 	// Создание таблицы ролей
 	_, err = DB.Exec(`
         CREATE TABLE IF NOT EXISTS roles (
@@ -99,6 +108,1002 @@ func createTables() error {
 		return err
 	}
 
+	// Уровни запасов продукта: минимальный остаток, точка заказа и целевой остаток
+	err = addColumns("products", [][2]string{
+		{"min_level", "INTEGER NOT NULL DEFAULT 0"},
+		{"reorder_point", "INTEGER NOT NULL DEFAULT 0"},
+		{"target_level", "INTEGER NOT NULL DEFAULT 0"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы оповещений о низком остатке
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS stock_alerts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INTEGER NOT NULL,
+			alert_type TEXT NOT NULL,
+			quantity INTEGER NOT NULL,
+			threshold INTEGER NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			resolved_at TIMESTAMP,
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание журнала движения товаров
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS stock_movements (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INTEGER NOT NULL,
+			movement_type TEXT NOT NULL,
+			quantity INTEGER NOT NULL,
+			unit_cost REAL NOT NULL DEFAULT 0,
+			reference_type TEXT NOT NULL DEFAULT '',
+			reference_id INTEGER NOT NULL DEFAULT 0,
+			note TEXT NOT NULL DEFAULT '',
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (product_id) REFERENCES products(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы поставщиков
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS suppliers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			contact_person TEXT NOT NULL DEFAULT '',
+			phone TEXT NOT NULL DEFAULT '',
+			email TEXT NOT NULL DEFAULT '',
+			address TEXT NOT NULL DEFAULT '',
+			lead_time_days INTEGER NOT NULL DEFAULT 0
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы условий поставки продуктов
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS supplier_products (
+			supplier_id INTEGER NOT NULL,
+			product_id INTEGER NOT NULL,
+			supplier_sku TEXT NOT NULL DEFAULT '',
+			last_price REAL NOT NULL DEFAULT 0,
+			unit_id INTEGER NOT NULL,
+			PRIMARY KEY (supplier_id, product_id),
+			FOREIGN KEY (supplier_id) REFERENCES suppliers(id) ON DELETE CASCADE,
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+			FOREIGN KEY (unit_id) REFERENCES units(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы заказов поставщикам
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS purchase_orders (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			supplier_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'draft',
+			note TEXT NOT NULL DEFAULT '',
+			expected_at TIMESTAMP,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			sent_at TIMESTAMP,
+			closed_at TIMESTAMP,
+			FOREIGN KEY (supplier_id) REFERENCES suppliers(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы строк заказов поставщикам
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS purchase_order_lines (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_id INTEGER NOT NULL,
+			product_id INTEGER NOT NULL,
+			quantity REAL NOT NULL,
+			unit_id INTEGER NOT NULL,
+			price REAL NOT NULL DEFAULT 0,
+			base_quantity INTEGER NOT NULL,
+			received_quantity INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (order_id) REFERENCES purchase_orders(id) ON DELETE CASCADE,
+			FOREIGN KEY (product_id) REFERENCES products(id),
+			FOREIGN KEY (unit_id) REFERENCES units(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы приёмок по заказам поставщикам
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS purchase_receipts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_id INTEGER NOT NULL,
+			line_id INTEGER NOT NULL,
+			quantity REAL NOT NULL,
+			unit_id INTEGER NOT NULL,
+			base_quantity INTEGER NOT NULL,
+			price REAL NOT NULL,
+			price_difference REAL NOT NULL DEFAULT 0,
+			movement_id INTEGER NOT NULL,
+			received_by TEXT NOT NULL DEFAULT '',
+			received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (order_id) REFERENCES purchase_orders(id) ON DELETE CASCADE,
+			FOREIGN KEY (line_id) REFERENCES purchase_order_lines(id) ON DELETE CASCADE,
+			FOREIGN KEY (movement_id) REFERENCES stock_movements(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы партий продуктов
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS lots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INTEGER NOT NULL,
+			lot_number TEXT NOT NULL,
+			received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			expiry_date TEXT,
+			quantity INTEGER NOT NULL DEFAULT 0,
+			blocked INTEGER NOT NULL DEFAULT 0,
+			blocked_reason TEXT NOT NULL DEFAULT '',
+			UNIQUE (product_id, lot_number),
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Партия, к которой относится движение товара
+	err = addColumns("stock_movements", [][2]string{
+		{"lot_id", "INTEGER NOT NULL DEFAULT 0"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы мест хранения (parent_id — для ячеек и полок)
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS locations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			parent_id INTEGER,
+			description TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY (parent_id) REFERENCES locations(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы остатков по местам хранения
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS location_stock (
+			location_id INTEGER NOT NULL,
+			product_id INTEGER NOT NULL,
+			quantity INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (location_id, product_id),
+			FOREIGN KEY (location_id) REFERENCES locations(id),
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы перемещений между местами хранения
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS stock_transfers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INTEGER NOT NULL,
+			from_location_id INTEGER NOT NULL DEFAULT 0,
+			to_location_id INTEGER NOT NULL DEFAULT 0,
+			quantity REAL NOT NULL,
+			unit_id INTEGER NOT NULL,
+			base_quantity INTEGER NOT NULL,
+			note TEXT NOT NULL DEFAULT '',
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (product_id) REFERENCES products(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Место хранения, к которому относится движение товара
+	err = addColumns("stock_movements", [][2]string{
+		{"location_id", "INTEGER NOT NULL DEFAULT 0"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы категорий продуктов (parent_id — для подкатегорий)
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS categories (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			parent_id INTEGER,
+			FOREIGN KEY (parent_id) REFERENCES categories(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Категория продукта
+	err = addColumns("products", [][2]string{
+		{"category_id", "INTEGER REFERENCES categories(id)"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы тегов
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS tags (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы пересечений products и tags
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS product_tags (
+			product_id INTEGER NOT NULL,
+			tag_id INTEGER NOT NULL,
+			PRIMARY KEY (product_id, tag_id),
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+			FOREIGN KEY (tag_id) REFERENCES tags(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Артикул продукта (уникален, если задан)
+	err = addColumns("products", [][2]string{
+		{"sku", "TEXT NOT NULL DEFAULT ''"},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_products_sku ON products (sku) WHERE sku <> '';`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы штрихкодов продуктов
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS product_barcodes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INTEGER NOT NULL,
+			code TEXT NOT NULL UNIQUE,
+			barcode_type TEXT NOT NULL,
+			weighted INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Себестоимость и цена продажи продукта за единицу измерения продукта
+	err = addColumns("products", [][2]string{
+		{"cost_price", "REAL NOT NULL DEFAULT 0"},
+		{"sale_price", "REAL NOT NULL DEFAULT 0"},
+		{"currency", "TEXT NOT NULL DEFAULT 'RUB'"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Создание журнала изменения цен (только добавление записей)
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS price_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INTEGER NOT NULL,
+			old_cost_price REAL,
+			old_sale_price REAL,
+			cost_price REAL NOT NULL,
+			sale_price REAL NOT NULL,
+			currency TEXT NOT NULL,
+			effective_from TIMESTAMP NOT NULL,
+			changed_by TEXT NOT NULL DEFAULT '',
+			changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы запланированных изменений цен
+	_, err = DB.Exec("CREATE TABLE IF NOT EXISTS scheduled_prices " + scheduledPricesColumns)
+	if err != nil {
+		return err
+	}
+	if err := relaxScheduledPrices(); err != nil {
+		return err
+	}
+
+	// Создание таблицы инвентаризаций
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS stocktakes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL DEFAULT '',
+			location_id INTEGER,
+			status TEXT NOT NULL DEFAULT 'open',
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			approved_by TEXT,
+			approved_at TIMESTAMP,
+			closed_by TEXT,
+			closed_at TIMESTAMP,
+			FOREIGN KEY (location_id) REFERENCES locations(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы строк инвентаризации со снимком ожидаемых остатков
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS stocktake_lines (
+			stocktake_id INTEGER NOT NULL,
+			product_id INTEGER NOT NULL,
+			expected_quantity INTEGER NOT NULL,
+			unit_cost REAL NOT NULL DEFAULT 0,
+			movement_id INTEGER,
+			PRIMARY KEY (stocktake_id, product_id),
+			FOREIGN KEY (stocktake_id) REFERENCES stocktakes(id) ON DELETE CASCADE,
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+			FOREIGN KEY (movement_id) REFERENCES stock_movements(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы подсчётов (несколько подсчётов одного продукта суммируются)
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS stocktake_counts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			stocktake_id INTEGER NOT NULL,
+			product_id INTEGER NOT NULL,
+			quantity INTEGER NOT NULL,
+			counted_by TEXT NOT NULL DEFAULT '',
+			counted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (stocktake_id, product_id) REFERENCES stocktake_lines(stocktake_id, product_id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Снимок инвентаризации: движения после него учитываются в расхождении
+	err = addColumns("stocktakes", [][2]string{
+		{"snapshot_movement_id", "INTEGER"},
+	})
+	if err != nil {
+		return err
+	}
+	err = addColumns("stocktake_lines", [][2]string{
+		{"moved_quantity", "INTEGER"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы резервов товара
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS reservations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INTEGER NOT NULL,
+			quantity INTEGER NOT NULL,
+			location_id INTEGER NOT NULL DEFAULT 0,
+			reference_type TEXT NOT NULL,
+			reference_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'active',
+			expires_at TIMESTAMP,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			closed_at TIMESTAMP,
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+		);
+		CREATE INDEX IF NOT EXISTS idx_reservations_reference ON reservations (reference_type, reference_id);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы блюд
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS dishes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT NOT NULL DEFAULT '',
+			yield INTEGER NOT NULL DEFAULT 1,
+			portion_size REAL NOT NULL DEFAULT 0,
+			portion_unit_id INTEGER,
+			FOREIGN KEY (portion_unit_id) REFERENCES units(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы ингредиентов блюд (рецептура на весь выход)
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS dish_ingredients (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			dish_id INTEGER NOT NULL,
+			product_id INTEGER NOT NULL,
+			quantity REAL NOT NULL,
+			unit_id INTEGER NOT NULL,
+			base_quantity REAL NOT NULL,
+			FOREIGN KEY (dish_id) REFERENCES dishes(id) ON DELETE CASCADE,
+			FOREIGN KEY (product_id) REFERENCES products(id),
+			FOREIGN KEY (unit_id) REFERENCES units(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы столиков
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS tables (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			capacity INTEGER NOT NULL DEFAULT 2,
+			status TEXT NOT NULL DEFAULT 'free'
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы меню (позиция — блюдо или продукт)
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS menu (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			dish_id INTEGER,
+			product_id INTEGER,
+			price REAL NOT NULL DEFAULT 0,
+			currency TEXT NOT NULL DEFAULT 'RUB',
+			active INTEGER NOT NULL DEFAULT 1,
+			FOREIGN KEY (dish_id) REFERENCES dishes(id),
+			FOREIGN KEY (product_id) REFERENCES products(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы заказов
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS orders (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			table_id INTEGER,
+			status TEXT NOT NULL DEFAULT 'open',
+			note TEXT NOT NULL DEFAULT '',
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (table_id) REFERENCES tables(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание таблицы строк заказов
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS order_items (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_id INTEGER NOT NULL,
+			menu_item_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			quantity INTEGER NOT NULL,
+			price REAL NOT NULL,
+			FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+			FOREIGN KEY (menu_item_id) REFERENCES menu(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Создание журнала переходов заказов между состояниями
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS order_status_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			order_id INTEGER NOT NULL,
+			from_status TEXT NOT NULL DEFAULT '',
+			to_status TEXT NOT NULL,
+			changed_by TEXT NOT NULL DEFAULT '',
+			changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Участок кухни, который готовит позицию меню
+	err = addColumns("menu", [][2]string{
+		{"station", "TEXT NOT NULL DEFAULT 'kitchen'"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Ручная доступность позиции меню (NULL — по остаткам)
+	err = addColumns("menu", [][2]string{
+		{"availability_override", "TEXT"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Состояние строк заказа на кухне
+	err = addColumns("order_items", [][2]string{
+		{"station", "TEXT NOT NULL DEFAULT 'kitchen'"},
+		{"kitchen_status", "TEXT NOT NULL DEFAULT 'pending'"},
+		{"started_at", "TIMESTAMP"},
+		{"started_by", "TEXT"},
+		{"completed_at", "TIMESTAMP"},
+		{"completed_by", "TEXT"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Выпуск полуфабрикатов по рецептурам
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS production_runs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			dish_id INTEGER NOT NULL,
+			product_id INTEGER NOT NULL,
+			batches REAL NOT NULL,
+			expected_quantity INTEGER NOT NULL,
+			actual_quantity INTEGER NOT NULL,
+			ingredient_cost REAL NOT NULL DEFAULT 0,
+			location_id INTEGER,
+			lot_id INTEGER,
+			note TEXT NOT NULL DEFAULT '',
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (dish_id) REFERENCES dishes(id),
+			FOREIGN KEY (product_id) REFERENCES products(id),
+			FOREIGN KEY (location_id) REFERENCES locations(id),
+			FOREIGN KEY (lot_id) REFERENCES lots(id)
+		);
+
+		CREATE TABLE IF NOT EXISTS production_materials (
+			run_id INTEGER NOT NULL,
+			product_id INTEGER NOT NULL,
+			quantity INTEGER NOT NULL,
+			unit_cost REAL NOT NULL DEFAULT 0,
+			PRIMARY KEY (run_id, product_id),
+			FOREIGN KEY (run_id) REFERENCES production_runs(id) ON DELETE CASCADE,
+			FOREIGN KEY (product_id) REFERENCES products(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Место хранения, из которого выпуск списывает ингредиенты
+	err = addColumns("production_runs", [][2]string{
+		{"source_location_id", "INTEGER"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Акты списания испорченного и утраченного товара
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS write_offs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INTEGER NOT NULL,
+			quantity INTEGER NOT NULL,
+			reason TEXT NOT NULL,
+			unit_cost REAL NOT NULL DEFAULT 0,
+			cost REAL NOT NULL DEFAULT 0,
+			location_id INTEGER,
+			lot_id INTEGER,
+			status TEXT NOT NULL DEFAULT 'pending',
+			note TEXT NOT NULL DEFAULT '',
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			reviewed_by TEXT,
+			reviewed_at TIMESTAMP,
+			FOREIGN KEY (product_id) REFERENCES products(id),
+			FOREIGN KEY (location_id) REFERENCES locations(id),
+			FOREIGN KEY (lot_id) REFERENCES lots(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Прогнозы расхода продуктов (пересчитываются фоновой задачей)
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS product_forecasts (
+			product_id INTEGER PRIMARY KEY,
+			available INTEGER NOT NULL DEFAULT 0,
+			lookback_days INTEGER NOT NULL,
+			average_daily_usage REAL NOT NULL DEFAULT 0,
+			trend_daily_usage REAL NOT NULL DEFAULT 0,
+			trend_slope REAL NOT NULL DEFAULT 0,
+			days_of_supply REAL,
+			stockout_date TEXT,
+			computed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Пересчёт единиц других размерностей для отдельных продуктов
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS product_unit_conversions (
+			product_id INTEGER NOT NULL,
+			unit_id INTEGER NOT NULL,
+			factor REAL NOT NULL,
+			PRIMARY KEY (product_id, unit_id),
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+			FOREIGN KEY (unit_id) REFERENCES units(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Уровни упаковки продуктов (лоток, коробка) в базовых единицах
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS product_packages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			product_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			quantity INTEGER NOT NULL,
+			UNIQUE (product_id, name),
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Штрихкод упаковки (NULL — штрихкод одной единицы продукта)
+	err = addColumns("product_barcodes", [][2]string{
+		{"package_id", "INTEGER REFERENCES product_packages(id) ON DELETE CASCADE"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Пищевая ценность продукта на 100 г (мл); NULL — не указана
+	err = addColumns("products", [][2]string{
+		{"energy_kcal", "REAL"},
+		{"protein", "REAL"},
+		{"fat", "REAL"},
+		{"carbohydrates", "REAL"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Аллергены продуктов
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS product_allergens (
+			product_id INTEGER NOT NULL,
+			allergen TEXT NOT NULL,
+			PRIMARY KEY (product_id, allergen),
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Оборудование для хранения с допустимым диапазоном температуры
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS storage_units (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			unit_type TEXT NOT NULL,
+			location_id INTEGER,
+			min_temp REAL NOT NULL,
+			max_temp REAL NOT NULL,
+			active INTEGER NOT NULL DEFAULT 1,
+			FOREIGN KEY (location_id) REFERENCES locations(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Журнал температуры
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS temperature_readings (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			storage_unit_id INTEGER NOT NULL,
+			temperature REAL NOT NULL,
+			humidity REAL,
+			in_range INTEGER NOT NULL,
+			note TEXT NOT NULL DEFAULT '',
+			recorded_by TEXT NOT NULL DEFAULT '',
+			recorded_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (storage_unit_id) REFERENCES storage_units(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Нарушения температурного режима и корректирующие действия по ним
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS temperature_alerts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			storage_unit_id INTEGER NOT NULL,
+			reading_id INTEGER NOT NULL,
+			temperature REAL NOT NULL,
+			min_temp REAL NOT NULL,
+			max_temp REAL NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			resolved_at TIMESTAMP,
+			FOREIGN KEY (storage_unit_id) REFERENCES storage_units(id),
+			FOREIGN KEY (reading_id) REFERENCES temperature_readings(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS corrective_actions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			alert_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			action TEXT NOT NULL DEFAULT '',
+			taken_by TEXT,
+			taken_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (alert_id) REFERENCES temperature_alerts(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Постоянные гости и их бонусные баллы
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS customers (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			phone TEXT NOT NULL DEFAULT '',
+			email TEXT NOT NULL DEFAULT '',
+			note TEXT NOT NULL DEFAULT '',
+			points INTEGER NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_phone ON customers (phone) WHERE phone <> '';`)
+	if err != nil {
+		return err
+	}
+
+	// Гость заказа, списанные и начисленные баллы
+	err = addColumns("orders", [][2]string{
+		{"customer_id", "INTEGER REFERENCES customers(id)"},
+		{"points_redeemed", "INTEGER NOT NULL DEFAULT 0"},
+		{"points_discount", "REAL NOT NULL DEFAULT 0"},
+		{"points_earned", "INTEGER NOT NULL DEFAULT 0"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Правила начисления баллов
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS loyalty_rules (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			spend_amount REAL NOT NULL,
+			points INTEGER NOT NULL,
+			min_order_total REAL NOT NULL DEFAULT 0,
+			menu_item_id INTEGER,
+			active INTEGER NOT NULL DEFAULT 1,
+			FOREIGN KEY (menu_item_id) REFERENCES menu(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Журнал начисления и списания баллов
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS loyalty_transactions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			customer_id INTEGER NOT NULL,
+			order_id INTEGER,
+			points INTEGER NOT NULL,
+			reason TEXT NOT NULL,
+			created_by TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (customer_id) REFERENCES customers(id),
+			FOREIGN KEY (order_id) REFERENCES orders(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Прайс-листы каналов продаж и цены продуктов в них
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS price_lists (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			description TEXT NOT NULL DEFAULT '',
+			currency TEXT NOT NULL DEFAULT 'RUB',
+			active INTEGER NOT NULL DEFAULT 1
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS price_list_items (
+			price_list_id INTEGER NOT NULL,
+			product_id INTEGER NOT NULL,
+			price REAL NOT NULL,
+			PRIMARY KEY (price_list_id, product_id),
+			FOREIGN KEY (price_list_id) REFERENCES price_lists(id) ON DELETE CASCADE,
+			FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Акции: процентные, фиксированные и «X + Y в подарок»
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS promotions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			promo_type TEXT NOT NULL,
+			value REAL NOT NULL DEFAULT 0,
+			buy_quantity INTEGER NOT NULL DEFAULT 0,
+			free_quantity INTEGER NOT NULL DEFAULT 0,
+			product_id INTEGER,
+			price_list_id INTEGER,
+			starts_at TIMESTAMP NOT NULL,
+			ends_at TIMESTAMP,
+			active INTEGER NOT NULL DEFAULT 1,
+			FOREIGN KEY (product_id) REFERENCES products(id),
+			FOREIGN KEY (price_list_id) REFERENCES price_lists(id)
+		);
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// scheduledPricesColumns — столбцы запланированных изменений цен. Цена или валюта,
+// не переданная в изменении, остаётся NULL и при применении не меняется.
+const scheduledPricesColumns = `(
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	product_id INTEGER NOT NULL,
+	cost_price REAL,
+	sale_price REAL,
+	currency TEXT,
+	effective_from TIMESTAMP NOT NULL,
+	created_by TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	applied_at TIMESTAMP,
+	cancelled_at TIMESTAMP,
+	FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE
+)`
+
+// relaxScheduledPrices пересоздаёт scheduled_prices, созданную с обязательными ценами
+// и валютой, сохраняя все записи
+func relaxScheduledPrices() error {
+	var notNull int
+	err := DB.QueryRow(`
+		SELECT COUNT(*) FROM pragma_table_info('scheduled_prices') WHERE name = 'cost_price' AND "notnull" = 1
+	`).Scan(&notNull)
+	if err != nil || notNull == 0 {
+		return err
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, query := range []string{
+		"ALTER TABLE scheduled_prices RENAME TO scheduled_prices_old",
+		"CREATE TABLE scheduled_prices " + scheduledPricesColumns,
+		`INSERT INTO scheduled_prices (id, product_id, cost_price, sale_price, currency, effective_from,
+			created_by, created_at, applied_at, cancelled_at)
+		SELECT id, product_id, cost_price, sale_price, currency, effective_from,
+			created_by, created_at, applied_at, cancelled_at
+		FROM scheduled_prices_old`,
+		"DROP TABLE scheduled_prices_old",
+	} {
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// addColumns добавляет в существующую таблицу недостающие столбцы (пары «имя, определение»)
+func addColumns(table string, columns [][2]string) error {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid          int
+			name, ctype  string
+			notNull, pk  int
+			defaultValue sql.NullString
+		)
+		if err := rows.Scan(&cid, &name, &ctype, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		existing[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		if existing[column[0]] {
+			continue
+		}
+		_, err = DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column[0], column[1]))
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -116,6 +1121,17 @@ func seedTables() error {
 		return err
 	}
 
+	// Размерности начальных единиц (количество продуктов хранится в г, мл и шт)
+	_, err = DB.Exec(`
+		UPDATE units SET
+			dimension = CASE abbreviation WHEN 'г' THEN 'mass' WHEN 'кг' THEN 'mass' ELSE 'volume' END,
+			factor = CASE abbreviation WHEN 'кг' THEN 1000 WHEN 'л' THEN 1000 ELSE 1 END
+		WHERE abbreviation IN ('г', 'кг', 'л', 'мл') AND dimension = 'count';
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Заполнение таблицы products начальными данными
 	_, err = DB.Exec(`
 		INSERT OR IGNORE INTO products (name, quantity, unit_id) VALUES
@@ -129,6 +1145,17 @@ func seedTables() error {
 		return err
 	}
 
+	// Заполнение таблицы locations начальными местами хранения
+	_, err = DB.Exec(`
+		INSERT OR IGNORE INTO locations (id, name) VALUES
+		(1, 'Кухня'),
+		(2, 'Холодильная камера'),
+		(3, 'Торговый зал');
+	`)
+	if err != nil {
+		return err
+	}
+
 	// Добавляем роли
 	_, err = DB.Exec(`
 	INSERT OR IGNORE INTO roles (id, name, description) VALUES
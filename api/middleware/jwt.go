@@ -39,6 +39,12 @@ type Claims struct {
 	jwt.RegisteredClaims
 }
 
+// GetClaims возвращает данные токена, сохранённые ValidateJWT в контексте запроса
+func GetClaims(r *http.Request) (*Claims, bool) {
+	claims, ok := r.Context().Value(claimsKey).(*Claims)
+	return claims, ok
+}
+
 func GenerateJWT(username string) (string, error) {
 	// Получаем роли пользователя
 	rows, err := database.DB.Query(`
@@ -123,3 +129,22 @@ func ValidateJWT(next http.HandlerFunc) http.HandlerFunc {
 		next.ServeHTTP(w, r)
 	}
 }
+
+// TokenFromQuery передаёт токен из параметра ?token= или cookie token в заголовок
+// Authorization: EventSource в браузере не умеет выставлять заголовки
+func TokenFromQuery(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "" {
+			token := r.URL.Query().Get("token")
+			if token == "" {
+				if cookie, err := r.Cookie("token"); err == nil {
+					token = cookie.Value
+				}
+			}
+			if token != "" {
+				r.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		next.ServeHTTP(w, r)
+	}
+}
@@ -11,26 +11,12 @@ import (
 func RoleCheck(requiredRoles ...string) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
-			claims, ok := r.Context().Value("claims").(*Claims)
-			if !ok {
+			if _, ok := GetClaims(r); !ok {
 				utils.RespondWithError(w, http.StatusUnauthorized, "User not authenticated")
 				return
 			}
 
-			hasRole := false
-			for _, requiredRole := range requiredRoles {
-				for _, role := range claims.Roles {
-					if role == requiredRole {
-						hasRole = true
-						break
-					}
-				}
-				if hasRole {
-					break
-				}
-			}
-
-			if !hasRole {
+			if !HasRole(r, requiredRoles...) {
 				utils.RespondWithError(w, http.StatusForbidden, "Insufficient permissions")
 				return
 			}
@@ -39,3 +25,19 @@ func RoleCheck(requiredRoles ...string) func(http.HandlerFunc) http.HandlerFunc
 		}
 	}
 }
+
+// HasRole сообщает, есть ли у автора запроса хотя бы одна из указанных ролей
+func HasRole(r *http.Request, roles ...string) bool {
+	claims, ok := GetClaims(r)
+	if !ok {
+		return false
+	}
+	for _, required := range roles {
+		for _, role := range claims.Roles {
+			if role == required {
+				return true
+			}
+		}
+	}
+	return false
+}
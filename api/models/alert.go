@@ -0,0 +1,24 @@
+package models
+
+// Типы оповещений об остатках
+const (
+	AlertTypeReorder = "reorder" // остаток достиг точки заказа
+	AlertTypeMinimum = "minimum" // остаток опустился ниже минимального
+)
+
+type StockAlert struct {
+	ID          int     `json:"id"`
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	AlertType   string  `json:"alert_type"`
+	Quantity    int     `json:"quantity"`
+	Threshold   int     `json:"threshold"`
+	CreatedAt   string  `json:"created_at"`
+	ResolvedAt  *string `json:"resolved_at,omitempty"`
+}
+
+// LowStockItem описывает продукт, остаток которого достиг точки заказа
+type LowStockItem struct {
+	Product           Product `json:"product"`
+	SuggestedQuantity int     `json:"suggested_quantity"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// Barcode — штрихкод продукта. Весовой штрихкод хранится префиксом из семи цифр,
+// остальные цифры кода при сканировании содержат вес.
+type Barcode struct {
+	ID          int    `json:"id"`
+	ProductID   int    `json:"product_id"`
+	Code        string `json:"code"`
+	BarcodeType string `json:"barcode_type"`
+	Weighted    bool   `json:"weighted"`
+	PackageID   *int   `json:"package_id,omitempty"` // штрихкод упаковки, а не одной единицы
+}
+
+// BarcodeLookup — результат поиска продукта по отсканированному штрихкоду
+type BarcodeLookup struct {
+	Product  Product         `json:"product"`
+	Barcode  Barcode         `json:"barcode"`
+	Package  *ProductPackage `json:"package,omitempty"`
+	Quantity *int            `json:"quantity,omitempty"` // вес из весового штрихкода или содержимое упаковки, в базовых единицах
+}
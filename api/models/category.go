@@ -0,0 +1,17 @@
+package models
+
+// Category — категория продуктов. Вложенные категории образуют дерево (молочные → молоко).
+type Category struct {
+	ID           int         `json:"id"`
+	Name         string      `json:"name"`
+	ParentID     *int        `json:"parent_id,omitempty"`
+	ProductCount int         `json:"product_count"` // продукты непосредственно в категории
+	TotalCount   int         `json:"total_count"`   // продукты в категории и всех подкатегориях
+	Children     []*Category `json:"children,omitempty"`
+}
+
+type Tag struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	ProductCount int    `json:"product_count"`
+}
@@ -0,0 +1,13 @@
+package models
+
+// ProductUnitConversion — пересчёт единицы другой размерности для конкретного продукта
+// (1 стакан муки = 130 г, 60 г = 1 яйцо). Factor — сколько базовых единиц продукта
+// в одной единице UnitID.
+type ProductUnitConversion struct {
+	ProductID        int     `json:"product_id"`
+	UnitID           int     `json:"unit_id"`
+	UnitName         string  `json:"unit_name"`
+	UnitAbbreviation string  `json:"unit_abbreviation"`
+	Factor           float64 `json:"factor"`
+	BaseUnit         string  `json:"base_unit"`
+}
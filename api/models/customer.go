@@ -0,0 +1,51 @@
+package models
+
+// Причины изменения баланса баллов
+const (
+	LoyaltyEarn   = "earn"   // начисление за оплаченный заказ
+	LoyaltyRedeem = "redeem" // списание в счёт скидки
+	LoyaltyRefund = "refund" // возврат списанных баллов при отмене заказа или снятии скидки
+)
+
+// Customer — постоянный гость с контактами и балансом бонусных баллов
+type Customer struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Phone     string `json:"phone"`
+	Email     string `json:"email"`
+	Note      string `json:"note"`
+	Points    int    `json:"points"`
+	CreatedAt string `json:"created_at"`
+}
+
+// LoyaltyRule — правило начисления баллов: Points баллов за каждые SpendAmount
+// суммы заказа (или только строк позиции меню MenuItemID), если заказ не меньше MinOrderTotal
+type LoyaltyRule struct {
+	ID            int     `json:"id"`
+	Name          string  `json:"name"`
+	SpendAmount   float64 `json:"spend_amount"`
+	Points        int     `json:"points"`
+	MinOrderTotal float64 `json:"min_order_total"`
+	MenuItemID    *int    `json:"menu_item_id,omitempty"`
+	Active        bool    `json:"active"`
+}
+
+// LoyaltyTransaction — изменение баланса баллов гостя
+type LoyaltyTransaction struct {
+	ID         int    `json:"id"`
+	CustomerID int    `json:"customer_id"`
+	OrderID    *int   `json:"order_id,omitempty"`
+	Points     int    `json:"points"` // положительное — начисление, отрицательное — списание
+	Reason     string `json:"reason"`
+	CreatedBy  string `json:"created_by"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// CustomerHistory — заказы гостя и движение его баллов
+type CustomerHistory struct {
+	Customer     Customer             `json:"customer"`
+	OrdersCount  int                  `json:"orders_count"` // оплаченных заказов
+	TotalSpent   float64              `json:"total_spent"`  // по оплаченным заказам, за вычетом скидок
+	Orders       []Order              `json:"orders"`
+	Transactions []LoyaltyTransaction `json:"transactions"`
+}
@@ -0,0 +1,46 @@
+package models
+
+// Dish — блюдо с рецептурой. Ингредиенты указаны на весь выход рецепта (Yield порций).
+type Dish struct {
+	ID            int              `json:"id"`
+	Name          string           `json:"name"`
+	Description   string           `json:"description"`
+	Yield         int              `json:"yield"`
+	PortionSize   float64          `json:"portion_size"`
+	PortionUnitID *int             `json:"portion_unit_id,omitempty"`
+	Ingredients   []DishIngredient `json:"ingredients"`
+	// Себестоимость порции по текущим ценам продуктов; видна только админам и менеджерам
+	CostPerPortion *float64 `json:"cost_per_portion,omitempty"`
+	Currency       string   `json:"currency,omitempty"`
+}
+
+// DishIngredient — строка рецептуры. Quantity указано в единице строки на весь выход,
+// BaseQuantity и PortionQuantity — в базовых единицах продукта на выход и на порцию.
+type DishIngredient struct {
+	ID              int      `json:"id"`
+	ProductID       int      `json:"product_id"`
+	ProductName     string   `json:"product_name,omitempty"`
+	Quantity        float64  `json:"quantity"`
+	UnitID          int      `json:"unit_id"`
+	BaseQuantity    float64  `json:"base_quantity"`
+	PortionQuantity float64  `json:"portion_quantity"`
+	PortionCost     *float64 `json:"portion_cost,omitempty"`
+}
+
+// DishCapacity — сколько порций блюда можно приготовить из свободного остатка
+type DishCapacity struct {
+	DishID      int                  `json:"dish_id"`
+	DishName    string               `json:"dish_name"`
+	Portions    int                  `json:"portions"`
+	Limiting    *IngredientCapacity  `json:"limiting_ingredient,omitempty"`
+	Ingredients []IngredientCapacity `json:"ingredients"`
+}
+
+// IngredientCapacity — ограничение по одному ингредиенту (количества в базовых единицах)
+type IngredientCapacity struct {
+	ProductID       int     `json:"product_id"`
+	ProductName     string  `json:"product_name"`
+	Available       int     `json:"available"`
+	PortionQuantity float64 `json:"portion_quantity"`
+	Portions        int     `json:"portions"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// ConsumptionForecast — прогноз расхода продукта по истории движений. Количества
+// указаны в базовых единицах в день; StockoutDate пуст, если по тренду остатка хватит
+// дольше горизонта прогноза или расхода нет.
+type ConsumptionForecast struct {
+	ProductID         int      `json:"product_id"`
+	ProductName       string   `json:"product_name"`
+	BaseUnit          string   `json:"base_unit"`
+	Available         int      `json:"available"`
+	LookbackDays      int      `json:"lookback_days"`
+	AverageDailyUsage float64  `json:"average_daily_usage"`
+	TrendDailyUsage   float64  `json:"trend_daily_usage"` // расход по линейному тренду на текущий день
+	TrendSlope        float64  `json:"trend_slope"`       // изменение дневного расхода за день
+	DaysOfSupply      *float64 `json:"days_of_supply"`    // на сколько дней хватит при среднем расходе
+	StockoutDate      *string  `json:"stockout_date"`     // дата исчерпания остатка по тренду
+	ComputedAt        string   `json:"computed_at"`
+}
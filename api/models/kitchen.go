@@ -0,0 +1,47 @@
+package models
+
+// Состояния строки заказа на кухне
+const (
+	KitchenPending    = "pending"     // ждёт приготовления
+	KitchenInProgress = "in_progress" // готовится
+	KitchenDone       = "done"        // готово
+)
+
+// DefaultStation — участок кухни по умолчанию
+const DefaultStation = "kitchen"
+
+// KitchenTicket — строка заказа в очереди кухни. Возраст считается от передачи заказа на кухню.
+type KitchenTicket struct {
+	OrderItemID int     `json:"order_item_id"`
+	OrderID     int     `json:"order_id"`
+	TableName   string  `json:"table_name,omitempty"`
+	Name        string  `json:"name"`
+	Quantity    int     `json:"quantity"`
+	Status      string  `json:"status"`
+	SentAt      string  `json:"sent_at"`
+	AgeSeconds  int     `json:"age_seconds"`
+	StartedAt   *string `json:"started_at,omitempty"`
+	StartedBy   *string `json:"started_by,omitempty"`
+}
+
+// KitchenStation — очередь одного участка кухни
+type KitchenStation struct {
+	Station string          `json:"station"`
+	Items   []KitchenTicket `json:"items"`
+}
+
+// Типы событий кухни
+const (
+	KitchenItemAdded     = "item_added"
+	KitchenItemStarted   = "item_started"
+	KitchenItemCompleted = "item_completed"
+	KitchenOrderRemoved  = "order_removed" // заказ подан или отменён и ушёл из очереди
+)
+
+// KitchenEvent — событие для экранов кухни
+type KitchenEvent struct {
+	Type        string `json:"type"`
+	OrderID     int    `json:"order_id"`
+	OrderItemID int    `json:"order_item_id,omitempty"`
+	Station     string `json:"station,omitempty"`
+}
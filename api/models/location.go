@@ -0,0 +1,36 @@
+package models
+
+// Location — место хранения (склад, кухня, торговый зал) или его ячейка/полка,
+// если указано родительское место
+type Location struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	ParentID    *int            `json:"parent_id,omitempty"`
+	Path        string          `json:"path"` // полный путь вида «Холодильная камера / Полка 2»
+	Description string          `json:"description"`
+	Stock       []LocationStock `json:"stock,omitempty"`
+}
+
+// LocationStock — остаток продукта в месте хранения (в базовых единицах)
+type LocationStock struct {
+	LocationID   int    `json:"location_id"`
+	LocationPath string `json:"location_path,omitempty"`
+	ProductID    int    `json:"product_id"`
+	ProductName  string `json:"product_name,omitempty"`
+	Quantity     int    `json:"quantity"`
+}
+
+// StockTransfer — перемещение продукта между местами хранения.
+// Нулевое место означает остаток, не распределённый по местам хранения.
+type StockTransfer struct {
+	ID             int     `json:"id"`
+	ProductID      int     `json:"product_id"`
+	FromLocationID int     `json:"from_location_id"`
+	ToLocationID   int     `json:"to_location_id"`
+	Quantity       float64 `json:"quantity"`
+	UnitID         int     `json:"unit_id"`
+	BaseQuantity   int     `json:"base_quantity"`
+	Note           string  `json:"note"`
+	CreatedBy      string  `json:"created_by"`
+	CreatedAt      string  `json:"created_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+// Lot — партия продукта с номером и сроком годности. Остаток партии входит
+// в общий остаток продукта; часть остатка может не относиться ни к одной партии.
+type Lot struct {
+	ID            int     `json:"id"`
+	ProductID     int     `json:"product_id"`
+	ProductName   string  `json:"product_name,omitempty"`
+	LotNumber     string  `json:"lot_number"`
+	ReceivedAt    string  `json:"received_at"`
+	ExpiryDate    *string `json:"expiry_date,omitempty"` // YYYY-MM-DD
+	Quantity      int     `json:"quantity"`
+	Blocked       bool    `json:"blocked"`
+	BlockedReason string  `json:"blocked_reason,omitempty"`
+	DaysToExpiry  *int    `json:"days_to_expiry,omitempty"`
+}
+
+// LotAllocation — ручной выбор партии при списании (количество в базовых единицах)
+type LotAllocation struct {
+	LotID    int `json:"lot_id"`
+	Quantity int `json:"quantity"`
+}
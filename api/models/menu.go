@@ -0,0 +1,29 @@
+package models
+
+// Ручное управление доступностью позиции меню
+const (
+	AvailabilityAvailable   = "available"   // позиция в продаже независимо от остатков
+	AvailabilityUnavailable = "unavailable" // позиция снята с продажи независимо от остатков
+)
+
+// MenuItem — позиция меню: блюдо по рецептуре либо продукт, продаваемый
+// в своей единице измерения (указывается ровно одно из DishID и ProductID)
+type MenuItem struct {
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	DishID    *int    `json:"dish_id,omitempty"`
+	ProductID *int    `json:"product_id,omitempty"`
+	Price     float64 `json:"price"`
+	Currency  string  `json:"currency"`
+	Station   string  `json:"station"` // участок кухни
+	Active    bool    `json:"active"`
+
+	// Доступность по остаткам: сколько порций можно продать из свободного остатка
+	// и можно ли продавать позицию с учётом ручного решения менеджера
+	AvailablePortions    int     `json:"available_portions"`
+	Available            bool    `json:"available"`
+	AvailabilityOverride *string `json:"availability_override"`
+
+	// Аллергены блюда (по всем ингредиентам) или продукта
+	Allergens []string `json:"allergens"`
+}
@@ -0,0 +1,30 @@
+package models
+
+// Типы движений товара
+const (
+	MovementReceipt    = "receipt"    // поступление
+	MovementIssue      = "issue"      // расход
+	MovementAdjustment = "adjustment" // корректировка остатка
+	MovementTransfer   = "transfer"   // перемещение между местами хранения
+	MovementReturn     = "return"     // возврат списанного (например, при отмене заказа)
+	MovementProduction = "production" // выпуск полуфабриката собственного производства
+	MovementWriteOff   = "write_off"  // списание испорченного или утраченного товара
+)
+
+// StockMovement — запись журнала движения товара. Количество указывается
+// в базовых единицах продукта: положительное — приход, отрицательное — расход.
+type StockMovement struct {
+	ID            int     `json:"id"`
+	ProductID     int     `json:"product_id"`
+	ProductName   string  `json:"product_name,omitempty"`
+	MovementType  string  `json:"movement_type"`
+	Quantity      int     `json:"quantity"`
+	UnitCost      float64 `json:"unit_cost"` // стоимость базовой единицы
+	LotID         int     `json:"lot_id,omitempty"`
+	LocationID    int     `json:"location_id,omitempty"`
+	ReferenceType string  `json:"reference_type,omitempty"`
+	ReferenceID   int     `json:"reference_id,omitempty"`
+	Note          string  `json:"note,omitempty"`
+	CreatedBy     string  `json:"created_by,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+}
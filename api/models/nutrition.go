@@ -0,0 +1,48 @@
+package models
+
+// Аллергены, которые обязательно указываются в меню
+const (
+	AllergenGluten      = "gluten"      // злаки, содержащие глютен
+	AllergenCrustaceans = "crustaceans" // ракообразные
+	AllergenEggs        = "eggs"        // яйца
+	AllergenFish        = "fish"        // рыба
+	AllergenPeanuts     = "peanuts"     // арахис
+	AllergenSoy         = "soy"         // соя
+	AllergenMilk        = "milk"        // молоко и лактоза
+	AllergenNuts        = "nuts"        // орехи
+	AllergenCelery      = "celery"      // сельдерей
+	AllergenMustard     = "mustard"     // горчица
+	AllergenSesame      = "sesame"      // кунжут
+	AllergenSulphites   = "sulphites"   // диоксид серы и сульфиты
+	AllergenLupin       = "lupin"       // люпин
+	AllergenMolluscs    = "molluscs"    // моллюски
+)
+
+// Allergens — допустимые аллергены в порядке вывода на этикетке
+var Allergens = []string{
+	AllergenGluten, AllergenCrustaceans, AllergenEggs, AllergenFish, AllergenPeanuts,
+	AllergenSoy, AllergenMilk, AllergenNuts, AllergenCelery, AllergenMustard,
+	AllergenSesame, AllergenSulphites, AllergenLupin, AllergenMolluscs,
+}
+
+// Nutrition — пищевая ценность: у продукта — на 100 г (мл), у блюда — на порцию
+type Nutrition struct {
+	EnergyKcal    float64 `json:"energy_kcal"`
+	Protein       float64 `json:"protein"`
+	Fat           float64 `json:"fat"`
+	Carbohydrates float64 `json:"carbohydrates"`
+}
+
+// DishLabel — этикетка блюда: аллергены всех ингредиентов и пищевая ценность порции.
+// MissingNutrition — ингредиенты без данных о пищевой ценности (или без пересчёта
+// штучного продукта в граммы); с ними пищевая ценность порции неполная.
+type DishLabel struct {
+	DishID           int       `json:"dish_id"`
+	DishName         string    `json:"dish_name"`
+	PortionSize      float64   `json:"portion_size"`
+	PortionUnit      string    `json:"portion_unit,omitempty"`
+	Allergens        []string  `json:"allergens"`
+	Nutrition        Nutrition `json:"nutrition"`
+	Complete         bool      `json:"complete"`
+	MissingNutrition []string  `json:"missing_nutrition,omitempty"`
+}
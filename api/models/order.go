@@ -0,0 +1,70 @@
+package models
+
+// Состояния заказа
+const (
+	OrderOpen          = "open"            // заказ формируется, остатки не затронуты
+	OrderSentToKitchen = "sent_to_kitchen" // передан на кухню, ингредиенты списаны
+	OrderServed        = "served"          // подан гостю
+	OrderPaid          = "paid"            // оплачен
+	OrderCancelled     = "cancelled"       // отменён, списанное возвращено
+)
+
+// OrderTransitions — допустимые переходы между состояниями заказа
+var OrderTransitions = map[string][]string{
+	OrderOpen:          {OrderSentToKitchen, OrderCancelled},
+	OrderSentToKitchen: {OrderServed, OrderCancelled},
+	OrderServed:        {OrderPaid, OrderCancelled},
+}
+
+type Order struct {
+	ID        int                 `json:"id"`
+	TableID   *int                `json:"table_id,omitempty"`
+	TableName string              `json:"table_name,omitempty"`
+	Status    string              `json:"status"`
+	Note      string              `json:"note"`
+	CreatedBy string              `json:"created_by"`
+	CreatedAt string              `json:"created_at"`
+	Total     float64             `json:"total"`
+	Items     []OrderItem         `json:"items"`
+	History   []OrderStatusChange `json:"history,omitempty"`
+	// Гость и его бонусные баллы: списанные в счёт скидки и начисленные при оплате
+	CustomerID     *int    `json:"customer_id,omitempty"`
+	CustomerName   string  `json:"customer_name,omitempty"`
+	PointsRedeemed int     `json:"points_redeemed"`
+	PointsDiscount float64 `json:"points_discount"`
+	PointsEarned   int     `json:"points_earned"`
+	AmountDue      float64 `json:"amount_due"` // к оплате: total за вычетом скидки
+}
+
+// OrderStatusChange — переход заказа в новое состояние
+type OrderStatusChange struct {
+	FromStatus string `json:"from_status"`
+	ToStatus   string `json:"to_status"`
+	ChangedBy  string `json:"changed_by"`
+	ChangedAt  string `json:"changed_at"`
+}
+
+// OrderItem — строка заказа; цена фиксируется из меню при добавлении
+type OrderItem struct {
+	ID         int     `json:"id"`
+	MenuItemID int     `json:"menu_item_id"`
+	Name       string  `json:"name"`
+	Quantity   int     `json:"quantity"`
+	Price      float64 `json:"price"`
+	Total      float64 `json:"total"`
+	// Участок и состояние приготовления на кухне
+	Station       string  `json:"station"`
+	KitchenStatus string  `json:"kitchen_status"`
+	StartedAt     *string `json:"started_at,omitempty"`
+	CompletedAt   *string `json:"completed_at,omitempty"`
+}
+
+// MissingIngredient — нехватка продукта для исполнения заказа (в базовых единицах)
+type MissingIngredient struct {
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name"`
+	BaseUnit    string `json:"base_unit"`
+	Required    int    `json:"required"`
+	Available   int    `json:"available"`
+	Missing     int    `json:"missing"`
+}
@@ -0,0 +1,26 @@
+package models
+
+// ProductPackage — уровень упаковки продукта (лоток, коробка). Quantity — сколько
+// базовых единиц продукта в одной упаковке; у упаковки может быть свой штрихкод.
+type ProductPackage struct {
+	ID        int      `json:"id"`
+	ProductID int      `json:"product_id"`
+	Name      string   `json:"name"`
+	Quantity  int      `json:"quantity"`
+	Barcode   *Barcode `json:"barcode,omitempty"`
+}
+
+// StockBreakdown — остаток, разложенный по упаковкам от крупных к мелким,
+// и остаток вне целых упаковок (в базовых единицах)
+type StockBreakdown struct {
+	Packages  []PackageCount `json:"packages"`
+	Remainder int            `json:"remainder"`
+	Text      string         `json:"text"` // например, «3 × коробка + 4 л»
+}
+
+// PackageCount — число целых упаковок одного уровня
+type PackageCount struct {
+	PackageID int    `json:"package_id"`
+	Name      string `json:"name"`
+	Count     int    `json:"count"`
+}
@@ -0,0 +1,31 @@
+package models
+
+// DefaultCurrency — валюта цен по умолчанию
+const DefaultCurrency = "RUB"
+
+// PriceChange — запись истории цен продукта. Цены указываются за единицу измерения продукта.
+type PriceChange struct {
+	ID            int      `json:"id"`
+	ProductID     int      `json:"product_id"`
+	OldCostPrice  *float64 `json:"old_cost_price,omitempty"`
+	OldSalePrice  *float64 `json:"old_sale_price,omitempty"`
+	CostPrice     float64  `json:"cost_price"`
+	SalePrice     float64  `json:"sale_price"`
+	Currency      string   `json:"currency"`
+	EffectiveFrom string   `json:"effective_from"`
+	ChangedBy     string   `json:"changed_by"`
+	ChangedAt     string   `json:"changed_at"`
+}
+
+// ScheduledPrice — изменение цены, которое вступит в силу в будущем
+type ScheduledPrice struct {
+	ID            int      `json:"id"`
+	ProductID     int      `json:"product_id"`
+	ProductName   string   `json:"product_name,omitempty"`
+	CostPrice     *float64 `json:"cost_price,omitempty"` // без значения — не меняется
+	SalePrice     *float64 `json:"sale_price,omitempty"`
+	Currency      *string  `json:"currency,omitempty"`
+	EffectiveFrom string   `json:"effective_from"`
+	CreatedBy     string   `json:"created_by"`
+	CreatedAt     string   `json:"created_at"`
+}
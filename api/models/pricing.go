@@ -0,0 +1,89 @@
+package models
+
+// Типы акций
+const (
+	PromotionPercentage = "percentage"  // скидка в процентах с цены
+	PromotionFixed      = "fixed"       // скидка суммой с единицы товара (без продукта — с корзины)
+	PromotionBuyXGetY   = "buy_x_get_y" // из каждых BuyQuantity + FreeQuantity единиц FreeQuantity бесплатно
+)
+
+// Источники цены строки корзины
+const (
+	PriceSourcePriceList = "price_list" // цена из прайс-листа
+	PriceSourceSalePrice = "sale_price" // цена продажи продукта
+)
+
+// PriceList — именованный прайс-лист канала продаж (магазин, опт, персонал)
+type PriceList struct {
+	ID          int             `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Currency    string          `json:"currency"`
+	Active      bool            `json:"active"`
+	Items       []PriceListItem `json:"items,omitempty"`
+}
+
+// PriceListItem — цена продукта в прайс-листе за единицу измерения продукта
+type PriceListItem struct {
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name,omitempty"`
+	Price       float64 `json:"price"`
+}
+
+// Promotion — акция, действующая с StartsAt по EndsAt (без EndsAt — бессрочно).
+// Без ProductID акция относится ко всем продуктам, без PriceListID — ко всем прайс-листам.
+type Promotion struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	PromoType    string  `json:"promo_type"`
+	Value        float64 `json:"value"` // процент или сумма скидки
+	BuyQuantity  int     `json:"buy_quantity,omitempty"`
+	FreeQuantity int     `json:"free_quantity,omitempty"`
+	ProductID    *int    `json:"product_id,omitempty"`
+	PriceListID  *int    `json:"price_list_id,omitempty"`
+	StartsAt     string  `json:"starts_at"`
+	EndsAt       *string `json:"ends_at,omitempty"`
+	Active       bool    `json:"active"`
+}
+
+// BasketItem — позиция корзины: количество в единице измерения продукта
+type BasketItem struct {
+	ProductID int     `json:"product_id"`
+	Quantity  float64 `json:"quantity"`
+}
+
+// BasketPrice — расчёт корзины: цены строк, применённые акции и итог
+type BasketPrice struct {
+	PriceListID   *int               `json:"price_list_id,omitempty"`
+	PriceListName string             `json:"price_list_name,omitempty"`
+	Currency      string             `json:"currency"`
+	PricedAt      string             `json:"priced_at"`
+	Lines         []BasketLine       `json:"lines"`
+	Subtotal      float64            `json:"subtotal"`
+	Applied       []AppliedPromotion `json:"applied"` // акции на всю корзину
+	Discount      float64            `json:"discount"`
+	Total         float64            `json:"total"`
+}
+
+// BasketLine — строка корзины. К строке применяется одна акция — самая выгодная для покупателя.
+type BasketLine struct {
+	ProductID   int                `json:"product_id"`
+	ProductName string             `json:"product_name"`
+	Quantity    float64            `json:"quantity"`
+	Unit        string             `json:"unit"`
+	UnitPrice   float64            `json:"unit_price"`
+	PriceSource string             `json:"price_source"`
+	Subtotal    float64            `json:"subtotal"`
+	Discount    float64            `json:"discount"`
+	Total       float64            `json:"total"`
+	Applied     []AppliedPromotion `json:"applied"`
+}
+
+// AppliedPromotion — применённая акция с пояснением расчёта
+type AppliedPromotion struct {
+	PromotionID int     `json:"promotion_id"`
+	Name        string  `json:"name"`
+	PromoType   string  `json:"promo_type"`
+	Discount    float64 `json:"discount"`
+	Explanation string  `json:"explanation"`
+}
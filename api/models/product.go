@@ -1,9 +1,34 @@
 package models
 
 type Product struct {
-	ID       int    `json:"id"`
-	Name     string `json:"name"`
-	Quantity int    `json:"quantity"`
-	UnitID   int    `json:"unit_id"`
-	Unit     Unit   `json:"unit,omitempty"`
+	ID           int       `json:"id"`
+	Name         string    `json:"name"`
+	SKU          string    `json:"sku"`
+	Quantity     int       `json:"quantity"`
+	UnitID       int       `json:"unit_id"`
+	MinLevel     int       `json:"min_level"`
+	ReorderPoint int       `json:"reorder_point"`
+	TargetLevel  int       `json:"target_level"`
+	CategoryID   *int      `json:"category_id,omitempty"`
+	Tags         []string  `json:"tags,omitempty"`
+	Barcodes     []Barcode `json:"barcodes,omitempty"`
+	// Аллергены и пищевая ценность на 100 г (мл); заменяются, только если переданы в запросе
+	Allergens []string   `json:"allergens,omitempty"`
+	Nutrition *Nutrition `json:"nutrition,omitempty"`
+	// Уровни упаковки и остаток, разложенный по ним
+	Packages       []ProductPackage `json:"packages,omitempty"`
+	StockBreakdown *StockBreakdown  `json:"stock_breakdown,omitempty"`
+	// Цены за единицу измерения продукта; себестоимость видна только админам и менеджерам
+	CostPrice *float64 `json:"cost_price,omitempty"`
+	SalePrice *float64 `json:"sale_price,omitempty"`
+	Currency  string   `json:"currency,omitempty"`
+	Unit      Unit     `json:"unit,omitempty"`
+	// Остатки по местам хранения; остаток вне мест хранения — Unassigned
+	Locations  []LocationStock `json:"locations,omitempty"`
+	Unassigned int             `json:"unassigned_quantity"`
+	// Зарезервированный остаток, остаток в заблокированных и просроченных партиях
+	// и свободный остаток (quantity − reserved − blocked)
+	Reserved  int `json:"reserved_quantity"`
+	Blocked   int `json:"blocked_quantity"`
+	Available int `json:"available_quantity"`
 }
@@ -0,0 +1,36 @@
+package models
+
+// ProductionRun — выпуск полуфабриката по рецептуре: ингредиенты списываются на Batches
+// выходов рецепта, а продукт приходует фактический выход. Количества указаны
+// в базовых единицах; Variance — отклонение фактического выхода от ожидаемого.
+type ProductionRun struct {
+	ID               int                  `json:"id"`
+	DishID           int                  `json:"dish_id"`
+	DishName         string               `json:"dish_name"`
+	ProductID        int                  `json:"product_id"`
+	ProductName      string               `json:"product_name"`
+	BaseUnit         string               `json:"base_unit"`
+	Batches          float64              `json:"batches"`
+	ExpectedQuantity int                  `json:"expected_quantity"`
+	ActualQuantity   int                  `json:"actual_quantity"`
+	Variance         int                  `json:"variance"`
+	VariancePercent  float64              `json:"variance_percent"`
+	IngredientCost   float64              `json:"ingredient_cost"`
+	UnitCost         float64              `json:"unit_cost"`                    // себестоимость базовой единицы выхода
+	LocationID       *int                 `json:"location_id,omitempty"`        // место хранения выхода
+	SourceLocationID *int                 `json:"source_location_id,omitempty"` // место хранения ингредиентов
+	LotID            *int                 `json:"lot_id,omitempty"`
+	Note             string               `json:"note"`
+	CreatedBy        string               `json:"created_by"`
+	CreatedAt        string               `json:"created_at"`
+	Ingredients      []ProductionMaterial `json:"ingredients,omitempty"`
+}
+
+// ProductionMaterial — ингредиент, списанный на выпуск
+type ProductionMaterial struct {
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    int     `json:"quantity"`
+	UnitCost    float64 `json:"unit_cost"`
+	Cost        float64 `json:"cost"`
+}
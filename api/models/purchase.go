@@ -0,0 +1,54 @@
+package models
+
+// Состояния заказа поставщику
+const (
+	PurchaseDraft             = "draft"
+	PurchaseSent              = "sent"
+	PurchasePartiallyReceived = "partially_received"
+	PurchaseReceived          = "received"
+	PurchaseCancelled         = "cancelled"
+)
+
+type PurchaseOrder struct {
+	ID           int                 `json:"id"`
+	SupplierID   int                 `json:"supplier_id"`
+	SupplierName string              `json:"supplier_name,omitempty"`
+	Status       string              `json:"status"`
+	Note         string              `json:"note"`
+	ExpectedAt   *string             `json:"expected_at,omitempty"`
+	CreatedBy    string              `json:"created_by"`
+	CreatedAt    string              `json:"created_at"`
+	SentAt       *string             `json:"sent_at,omitempty"`
+	ClosedAt     *string             `json:"closed_at,omitempty"`
+	Total        float64             `json:"total"`
+	Lines        []PurchaseOrderLine `json:"lines"`
+	Receipts     []PurchaseReceipt   `json:"receipts,omitempty"`
+}
+
+// PurchaseOrderLine — строка заказа. Количество и цена указываются в единице строки,
+// BaseQuantity и ReceivedQuantity — в базовых единицах продукта.
+type PurchaseOrderLine struct {
+	ID               int     `json:"id"`
+	ProductID        int     `json:"product_id"`
+	ProductName      string  `json:"product_name,omitempty"`
+	Quantity         float64 `json:"quantity"`
+	UnitID           int     `json:"unit_id"`
+	Price            float64 `json:"price"`
+	BaseQuantity     int     `json:"base_quantity"`
+	ReceivedQuantity int     `json:"received_quantity"`
+}
+
+// PurchaseReceipt — факт приёмки по строке заказа с расхождением в цене
+type PurchaseReceipt struct {
+	ID              int     `json:"id"`
+	LineID          int     `json:"line_id"`
+	ProductID       int     `json:"product_id"`
+	Quantity        float64 `json:"quantity"`
+	UnitID          int     `json:"unit_id"`
+	BaseQuantity    int     `json:"base_quantity"`
+	Price           float64 `json:"price"`
+	PriceDifference float64 `json:"price_difference"` // (факт − заказ) × количество
+	MovementID      int     `json:"movement_id"`
+	ReceivedBy      string  `json:"received_by"`
+	ReceivedAt      string  `json:"received_at"`
+}
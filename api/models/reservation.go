@@ -0,0 +1,25 @@
+package models
+
+// Состояния резерва
+const (
+	ReservationActive    = "active"    // товар удерживается
+	ReservationFulfilled = "fulfilled" // резерв списан движением расхода
+	ReservationReleased  = "released"  // резерв снят при отмене
+	ReservationExpired   = "expired"   // резерв истёк
+)
+
+// Reservation — резерв товара под документ (например, заказ). Количество — в базовых единицах.
+type Reservation struct {
+	ID            int     `json:"id"`
+	ProductID     int     `json:"product_id"`
+	ProductName   string  `json:"product_name,omitempty"`
+	Quantity      int     `json:"quantity"`
+	LocationID    int     `json:"location_id,omitempty"`
+	ReferenceType string  `json:"reference_type"`
+	ReferenceID   int     `json:"reference_id"`
+	Status        string  `json:"status"`
+	ExpiresAt     *string `json:"expires_at,omitempty"`
+	CreatedBy     string  `json:"created_by"`
+	CreatedAt     string  `json:"created_at"`
+	ClosedAt      *string `json:"closed_at,omitempty"`
+}
@@ -0,0 +1,40 @@
+package models
+
+// ShoppingList — список закупки, сгруппированный по поставщикам. Продукты без
+// известного поставщика попадают в группу без SupplierID.
+type ShoppingList struct {
+	GeneratedAt  string          `json:"generated_at"`
+	LookbackDays int             `json:"lookback_days"`
+	CoverageDays int             `json:"coverage_days"`
+	Currency     string          `json:"currency"`
+	Groups       []ShoppingGroup `json:"groups"`
+	Total        float64         `json:"total"`
+}
+
+// ShoppingGroup — строки списка закупки одного поставщика
+type ShoppingGroup struct {
+	SupplierID   *int           `json:"supplier_id"`
+	SupplierName string         `json:"supplier_name"`
+	LeadTimeDays int            `json:"lead_time_days"`
+	Lines        []ShoppingLine `json:"lines"`
+	Total        float64        `json:"total"`
+}
+
+// ShoppingLine — продукт к закупке. Остатки и потребность указаны в базовых единицах,
+// OrderQuantity — в единице поставщика (UnitID), округлённое вверх до целых.
+type ShoppingLine struct {
+	ProductID         int     `json:"product_id"`
+	ProductName       string  `json:"product_name"`
+	BaseUnit          string  `json:"base_unit"`
+	Available         int     `json:"available"`
+	OnOrder           int     `json:"on_order"`
+	AverageDailyUsage float64 `json:"average_daily_usage"`
+	ReorderPoint      int     `json:"reorder_point"`
+	RequiredLevel     int     `json:"required_level"`
+	SuggestedQuantity int     `json:"suggested_quantity"`
+	OrderQuantity     float64 `json:"order_quantity"`
+	UnitID            int     `json:"unit_id"`
+	UnitAbbreviation  string  `json:"unit"`
+	Price             float64 `json:"price"`
+	Amount            float64 `json:"amount"`
+}
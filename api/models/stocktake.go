@@ -0,0 +1,50 @@
+package models
+
+// Состояния инвентаризации
+const (
+	StocktakeOpen      = "open"      // идёт подсчёт
+	StocktakeApproved  = "approved"  // расхождения утверждены менеджером
+	StocktakeClosed    = "closed"    // расхождения проведены корректировками
+	StocktakeCancelled = "cancelled" // отменена без изменения остатков
+)
+
+// Stocktake — сессия инвентаризации. Без места хранения пересчитывается
+// остаток вне мест хранения. Стоимость расхождений видна только админам и менеджерам.
+type Stocktake struct {
+	ID            int             `json:"id"`
+	Name          string          `json:"name"`
+	LocationID    *int            `json:"location_id,omitempty"`
+	Status        string          `json:"status"`
+	CreatedBy     string          `json:"created_by"`
+	CreatedAt     string          `json:"created_at"`
+	ApprovedBy    *string         `json:"approved_by,omitempty"`
+	ApprovedAt    *string         `json:"approved_at,omitempty"`
+	ClosedBy      *string         `json:"closed_by,omitempty"`
+	ClosedAt      *string         `json:"closed_at,omitempty"`
+	VarianceValue *float64        `json:"variance_value,omitempty"`
+	Lines         []StocktakeLine `json:"lines,omitempty"`
+}
+
+// StocktakeLine — строка инвентаризации. Количества указаны в базовых единицах;
+// CountedQuantity и Variance пусты, пока продукт не подсчитан.
+type StocktakeLine struct {
+	ProductID        int              `json:"product_id"`
+	ProductName      string           `json:"product_name"`
+	ExpectedQuantity int              `json:"expected_quantity"` // снимок при открытии
+	MovedQuantity    int              `json:"moved_quantity"`    // движения после снимка
+	CountedQuantity  *int             `json:"counted_quantity"`
+	Variance         *int             `json:"variance"`
+	UnitCost         *float64         `json:"unit_cost,omitempty"`
+	VarianceValue    *float64         `json:"variance_value,omitempty"`
+	MovementID       *int             `json:"movement_id,omitempty"`
+	Counts           []StocktakeCount `json:"counts,omitempty"`
+}
+
+// StocktakeCount — подсчёт продукта одним пользователем
+type StocktakeCount struct {
+	ID        int    `json:"id"`
+	ProductID int    `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+	CountedBy string `json:"counted_by"`
+	CountedAt string `json:"counted_at"`
+}
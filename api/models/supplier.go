@@ -0,0 +1,22 @@
+package models
+
+type Supplier struct {
+	ID            int               `json:"id"`
+	Name          string            `json:"name"`
+	ContactPerson string            `json:"contact_person"`
+	Phone         string            `json:"phone"`
+	Email         string            `json:"email"`
+	Address       string            `json:"address"`
+	LeadTimeDays  int               `json:"lead_time_days"`
+	Products      []SupplierProduct `json:"products,omitempty"`
+}
+
+// SupplierProduct — условия поставки продукта конкретным поставщиком
+type SupplierProduct struct {
+	SupplierID  int     `json:"supplier_id"`
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name,omitempty"`
+	SupplierSKU string  `json:"supplier_sku"`
+	LastPrice   float64 `json:"last_price"`
+	UnitID      int     `json:"unit_id"` // единица, за которую указана цена
+}
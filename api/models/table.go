@@ -0,0 +1,19 @@
+package models
+
+// Состояния столика
+const (
+	TableFree         = "free"
+	TableOccupied     = "occupied"
+	TableReserved     = "reserved"
+	TableOutOfService = "out_of_service"
+)
+
+// DiningTable — столик в зале
+type DiningTable struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Capacity int    `json:"capacity"`
+	Status   string `json:"status"`
+	// Незакрытые заказы столика
+	OpenOrders []int `json:"open_orders,omitempty"`
+}
@@ -0,0 +1,94 @@
+package models
+
+// Типы оборудования для хранения
+const (
+	StorageFridge   = "fridge"    // холодильник
+	StorageFreezer  = "freezer"   // морозильник
+	StorageColdRoom = "cold_room" // холодильная камера
+	StorageDry      = "dry_store" // сухой склад
+)
+
+// Состояния корректирующего действия
+const (
+	CorrectiveActionPending = "pending" // нарушение ещё не отработано
+	CorrectiveActionDone    = "done"    // действие выполнено и описано
+)
+
+// StorageUnit — холодильник, морозильник или другое место хранения с допустимым
+// диапазоном температуры (°C); может быть привязан к месту хранения склада
+type StorageUnit struct {
+	ID          int                 `json:"id"`
+	Name        string              `json:"name"`
+	UnitType    string              `json:"unit_type"`
+	LocationID  *int                `json:"location_id,omitempty"`
+	MinTemp     float64             `json:"min_temp"`
+	MaxTemp     float64             `json:"max_temp"`
+	Active      bool                `json:"active"`
+	LastReading *TemperatureReading `json:"last_reading,omitempty"`
+	OpenAlert   bool                `json:"open_alert"`
+}
+
+// TemperatureReading — запись журнала температуры: кто и когда снял показание
+type TemperatureReading struct {
+	ID              int      `json:"id"`
+	StorageUnitID   int      `json:"storage_unit_id"`
+	StorageUnitName string   `json:"storage_unit_name,omitempty"`
+	Temperature     float64  `json:"temperature"`
+	Humidity        *float64 `json:"humidity,omitempty"`
+	InRange         bool     `json:"in_range"`
+	Note            string   `json:"note"`
+	RecordedBy      string   `json:"recorded_by"`
+	RecordedAt      string   `json:"recorded_at"`
+}
+
+// TemperatureAlert — выход температуры за допустимый диапазон. Оповещение создаётся
+// первым показанием вне диапазона и закрывается первым показанием в диапазоне.
+type TemperatureAlert struct {
+	ID              int                `json:"id"`
+	StorageUnitID   int                `json:"storage_unit_id"`
+	StorageUnitName string             `json:"storage_unit_name"`
+	ReadingID       int                `json:"reading_id"`
+	Temperature     float64            `json:"temperature"`
+	MinTemp         float64            `json:"min_temp"`
+	MaxTemp         float64            `json:"max_temp"`
+	CreatedAt       string             `json:"created_at"`
+	ResolvedAt      *string            `json:"resolved_at,omitempty"`
+	Actions         []CorrectiveAction `json:"actions"`
+}
+
+// CorrectiveAction — корректирующее действие по нарушению температурного режима
+type CorrectiveAction struct {
+	ID        int     `json:"id"`
+	AlertID   int     `json:"alert_id"`
+	Status    string  `json:"status"`
+	Action    string  `json:"action"`
+	TakenBy   *string `json:"taken_by,omitempty"`
+	TakenAt   *string `json:"taken_at,omitempty"`
+	CreatedAt string  `json:"created_at"`
+}
+
+// ComplianceReport — соблюдение температурного режима за период для проверяющих
+type ComplianceReport struct {
+	From      string              `json:"from"`
+	To        string              `json:"to"`
+	Units     []StorageCompliance `json:"units"`
+	Incidents []TemperatureAlert  `json:"incidents"`
+}
+
+// StorageCompliance — показания одного места хранения за период. DaysWithoutReadings —
+// дни периода, за которые нет ни одной записи в журнале.
+type StorageCompliance struct {
+	StorageUnitID       int      `json:"storage_unit_id"`
+	Name                string   `json:"name"`
+	UnitType            string   `json:"unit_type"`
+	MinTemp             float64  `json:"min_temp"`
+	MaxTemp             float64  `json:"max_temp"`
+	Readings            int      `json:"readings"`
+	OutOfRange          int      `json:"out_of_range"`
+	LowestRecorded      *float64 `json:"lowest_recorded,omitempty"`
+	HighestRecorded     *float64 `json:"highest_recorded,omitempty"`
+	CompliancePercent   float64  `json:"compliance_percent"`
+	DaysWithoutReadings int      `json:"days_without_readings"`
+	Alerts              int      `json:"alerts"`
+	PendingActions      int      `json:"pending_actions"`
+}
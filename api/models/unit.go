@@ -1,7 +1,24 @@
 package models
 
+// Размерности единиц измерения. Количество продукта хранится в базовой единице
+// размерности его единицы измерения (граммы, миллилитры, штуки).
+const (
+	DimensionMass   = "mass"
+	DimensionVolume = "volume"
+	DimensionCount  = "count"
+)
+
 type Unit struct {
-	ID           int    `json:"id"`
-	Name         string `json:"name"`
-	Abbreviation string `json:"abbreviation"`
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	Abbreviation string  `json:"abbreviation"`
+	Dimension    string  `json:"dimension"`
+	Factor       float64 `json:"factor"` // сколько базовых единиц размерности в одной единице
+}
+
+// BaseUnits — обозначения базовых единиц размерностей
+var BaseUnits = map[string]string{
+	DimensionMass:   "г",
+	DimensionVolume: "мл",
+	DimensionCount:  "шт",
 }
@@ -0,0 +1,22 @@
+package models
+
+// ValuationItem — стоимость остатка одного продукта на дату.
+// Количество указано в базовых единицах, средняя стоимость — за базовую единицу.
+type ValuationItem struct {
+	ProductID    int     `json:"product_id"`
+	ProductName  string  `json:"product_name"`
+	Quantity     int     `json:"quantity"`
+	BaseUnit     string  `json:"base_unit"`
+	FIFOValue    float64 `json:"fifo_value"`
+	AverageCost  float64 `json:"average_cost"`
+	AverageValue float64 `json:"average_value"`
+}
+
+// ValuationReport — оценка складских остатков методами FIFO и скользящей средней
+type ValuationReport struct {
+	AsOf         string          `json:"as_of"`
+	Currency     string          `json:"currency"`
+	Items        []ValuationItem `json:"items"`
+	FIFOTotal    float64         `json:"fifo_total"`
+	AverageTotal float64         `json:"average_total"`
+}
@@ -0,0 +1,64 @@
+package models
+
+// Причины списания
+const (
+	WriteOffExpired         = "expired"          // истёк срок годности
+	WriteOffDamaged         = "damaged"          // порча, повреждение
+	WriteOffPreparationLoss = "preparation_loss" // потери при приготовлении
+	WriteOffTheft           = "theft"            // недостача, хищение
+)
+
+// Состояния акта списания
+const (
+	WriteOffPending  = "pending"  // ждёт утверждения менеджером
+	WriteOffApproved = "approved" // проведён, товар списан
+	WriteOffRejected = "rejected" // отклонён, остатки не изменились
+)
+
+// WriteOff — акт списания испорченного или утраченного товара. Количество указано
+// в базовых единицах; стоимость видна только админам и менеджерам.
+type WriteOff struct {
+	ID          int      `json:"id"`
+	ProductID   int      `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	Quantity    int      `json:"quantity"`
+	BaseUnit    string   `json:"base_unit"`
+	Reason      string   `json:"reason"`
+	UnitCost    *float64 `json:"unit_cost,omitempty"`
+	Cost        *float64 `json:"cost,omitempty"`
+	Currency    string   `json:"currency"`
+	LocationID  *int     `json:"location_id,omitempty"`
+	LotID       *int     `json:"lot_id,omitempty"`
+	Status      string   `json:"status"`
+	Note        string   `json:"note"`
+	CreatedBy   string   `json:"created_by"`
+	CreatedAt   string   `json:"created_at"`
+	ReviewedBy  *string  `json:"reviewed_by,omitempty"`
+	ReviewedAt  *string  `json:"reviewed_at,omitempty"`
+}
+
+// WasteReport — стоимость проведённых списаний за период по продуктам и причинам
+type WasteReport struct {
+	From      string           `json:"from"`
+	To        string           `json:"to"`
+	Currency  string           `json:"currency"`
+	ByProduct []WasteByProduct `json:"by_product"`
+	ByReason  []WasteByReason  `json:"by_reason"`
+	Total     float64          `json:"total"`
+}
+
+// WasteByProduct — списания одного продукта (количество в базовых единицах)
+type WasteByProduct struct {
+	ProductID   int     `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Quantity    int     `json:"quantity"`
+	BaseUnit    string  `json:"base_unit"`
+	Cost        float64 `json:"cost"`
+}
+
+// WasteByReason — списания по одной причине
+type WasteByReason struct {
+	Reason string  `json:"reason"`
+	Count  int     `json:"count"`
+	Cost   float64 `json:"cost"`
+}
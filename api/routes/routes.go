@@ -52,5 +52,345 @@ func RegisterRoutes(router *mux.Router) {
 			middleware.RoleCheck("admin", "manager")(controllers.CreateProduct),
 		),
 	).Methods("POST")
+	router.HandleFunc(
+		"/api/products/update/{id}",
+		middleware.ValidateJWT(
+			middleware.RoleCheck("admin", "manager")(controllers.UpdateProduct),
+		),
+	).Methods("PUT")
+
+	// Штрихкоды продуктов
+	router.HandleFunc("/api/products/barcode/{code}",
+		middleware.ValidateJWT(controllers.GetProductByBarcode)).Methods("GET")
+	router.HandleFunc("/api/products/barcodes/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.AddProductBarcode))).Methods("POST")
+	router.HandleFunc("/api/products/barcodes/{id}/{barcode_id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteProductBarcode))).Methods("DELETE")
+
+	// Цены продуктов и их история
+	router.HandleFunc("/api/products/prices/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.SetProductPrice))).Methods("PUT")
+	router.HandleFunc("/api/products/prices/history/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetPriceHistory))).Methods("GET")
+	router.HandleFunc("/api/products/prices/scheduled",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetScheduledPrices))).Methods("GET")
+	router.HandleFunc("/api/products/prices/scheduled/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CancelScheduledPrice))).Methods("DELETE")
+
+	// Пересчёт единиц других размерностей для продуктов
+	router.HandleFunc("/api/products/conversions/{id}",
+		middleware.ValidateJWT(controllers.GetProductConversions)).Methods("GET")
+	router.HandleFunc("/api/products/conversions/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.SetProductConversion))).Methods("PUT")
+	router.HandleFunc("/api/products/conversions/{id}/{unit_id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteProductConversion))).Methods("DELETE")
+
+	// Аллергены и пищевая ценность продуктов
+	router.HandleFunc("/api/products/nutrition/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.SetProductNutrition))).Methods("PUT")
+
+	// Уровни упаковки продуктов
+	router.HandleFunc("/api/products/packages/{id}",
+		middleware.ValidateJWT(controllers.GetProductPackages)).Methods("GET")
+	router.HandleFunc("/api/products/packages/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.AddProductPackage))).Methods("POST")
+	router.HandleFunc("/api/products/packages/{id}/{package_id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteProductPackage))).Methods("DELETE")
+
+	// Категории и теги продуктов
+	router.HandleFunc("/api/categories/all",
+		middleware.ValidateJWT(controllers.GetCategories)).Methods("GET")
+	router.HandleFunc("/api/categories/tree",
+		middleware.ValidateJWT(controllers.GetCategoryTree)).Methods("GET")
+	router.HandleFunc("/api/categories/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreateCategory))).Methods("POST")
+	router.HandleFunc("/api/categories/update/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.UpdateCategory))).Methods("PUT")
+	router.HandleFunc("/api/categories/delete/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteCategory))).Methods("DELETE")
+	router.HandleFunc("/api/tags/all",
+		middleware.ValidateJWT(controllers.GetTags)).Methods("GET")
+
+	// Уровни запасов и оповещения о низком остатке
+	router.HandleFunc("/api/products/low-stock",
+		middleware.ValidateJWT(controllers.GetLowStockProducts)).Methods("GET")
+	router.HandleFunc("/api/alerts/stock",
+		middleware.ValidateJWT(controllers.GetStockAlerts)).Methods("GET")
+	router.HandleFunc(
+		"/api/products/levels/{id}",
+		middleware.ValidateJWT(
+			middleware.RoleCheck("admin", "manager")(controllers.UpdateProductLevels),
+		),
+	).Methods("PUT")
+
+	// Журнал движения товаров (с себестоимостью — только для админов и менеджеров)
+	router.HandleFunc("/api/stock/movements",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetStockMovements))).Methods("GET")
+	router.HandleFunc("/api/stock/receive",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.ReceiveStock))).Methods("POST")
+	router.HandleFunc("/api/stock/issue",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.IssueStock))).Methods("POST")
+	router.HandleFunc("/api/stock/transfer",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.TransferStock))).Methods("POST")
+
+	// Маршруты для мест хранения
+	router.HandleFunc("/api/locations/all",
+		middleware.ValidateJWT(controllers.GetLocations)).Methods("GET")
+	router.HandleFunc("/api/locations/get/{id}",
+		middleware.ValidateJWT(controllers.GetLocation)).Methods("GET")
+	router.HandleFunc("/api/locations/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreateLocation))).Methods("POST")
+	router.HandleFunc("/api/locations/update/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.UpdateLocation))).Methods("PUT")
+	router.HandleFunc("/api/locations/delete/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteLocation))).Methods("DELETE")
+
+	// Холодильное оборудование и журнал температуры
+	router.HandleFunc("/api/storage-units/all",
+		middleware.ValidateJWT(controllers.GetStorageUnits)).Methods("GET")
+	router.HandleFunc("/api/storage-units/get/{id}",
+		middleware.ValidateJWT(controllers.GetStorageUnit)).Methods("GET")
+	router.HandleFunc("/api/storage-units/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreateStorageUnit))).Methods("POST")
+	router.HandleFunc("/api/storage-units/update/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.UpdateStorageUnit))).Methods("PUT")
+	router.HandleFunc("/api/storage-units/delete/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteStorageUnit))).Methods("DELETE")
+	router.HandleFunc("/api/temperature/readings/all",
+		middleware.ValidateJWT(controllers.GetTemperatureReadings)).Methods("GET")
+	router.HandleFunc("/api/temperature/readings/add",
+		middleware.ValidateJWT(controllers.AddTemperatureReading)).Methods("POST")
+	router.HandleFunc("/api/temperature/alerts/all",
+		middleware.ValidateJWT(controllers.GetTemperatureAlerts)).Methods("GET")
+	router.HandleFunc("/api/temperature/actions/complete/{id}",
+		middleware.ValidateJWT(controllers.CompleteCorrectiveAction)).Methods("PUT")
+
+	// Партии и сроки годности
+	router.HandleFunc("/api/lots/all",
+		middleware.ValidateJWT(controllers.GetLots)).Methods("GET")
+	router.HandleFunc("/api/lots/expiring",
+		middleware.ValidateJWT(controllers.GetExpiringLots)).Methods("GET")
+
+	// Маршруты для поставщиков
+	router.HandleFunc("/api/suppliers/all",
+		middleware.ValidateJWT(controllers.GetSuppliers)).Methods("GET")
+	router.HandleFunc("/api/suppliers/get/{id}",
+		middleware.ValidateJWT(controllers.GetSupplier)).Methods("GET")
+	router.HandleFunc("/api/suppliers/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreateSupplier))).Methods("POST")
+	router.HandleFunc("/api/suppliers/update/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.UpdateSupplier))).Methods("PUT")
+	router.HandleFunc("/api/suppliers/delete/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteSupplier))).Methods("DELETE")
+	router.HandleFunc("/api/suppliers/products/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.SetSupplierProduct))).Methods("PUT")
+	router.HandleFunc("/api/suppliers/products/{id}/{product_id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteSupplierProduct))).Methods("DELETE")
+
+	// Прогноз расхода
+	router.HandleFunc("/api/forecasts/get/{id}",
+		middleware.ValidateJWT(controllers.GetProductForecast)).Methods("GET")
+	router.HandleFunc("/api/forecasts/recalculate",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.RecalculateForecasts))).Methods("POST")
+
+	// Список закупки
+	router.HandleFunc("/api/shopping-list",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetShoppingList))).Methods("GET")
+	router.HandleFunc("/api/shopping-list/purchase-orders",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreateShoppingPurchaseOrders))).Methods("POST")
+
+	// Маршруты для заказов поставщикам
+	router.HandleFunc("/api/purchase-orders/all",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetPurchaseOrders))).Methods("GET")
+	router.HandleFunc("/api/purchase-orders/get/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetPurchaseOrder))).Methods("GET")
+	router.HandleFunc("/api/purchase-orders/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreatePurchaseOrder))).Methods("POST")
+	router.HandleFunc("/api/purchase-orders/update/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.UpdatePurchaseOrder))).Methods("PUT")
+	router.HandleFunc("/api/purchase-orders/send/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.SendPurchaseOrder))).Methods("POST")
+	router.HandleFunc("/api/purchase-orders/cancel/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CancelPurchaseOrder))).Methods("POST")
+	router.HandleFunc("/api/purchase-orders/receive/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.ReceivePurchaseOrder))).Methods("POST")
+
+	// Блюда и рецептуры
+	router.HandleFunc("/api/dishes/all",
+		middleware.ValidateJWT(controllers.GetDishes)).Methods("GET")
+	router.HandleFunc("/api/dishes/get/{id}",
+		middleware.ValidateJWT(controllers.GetDish)).Methods("GET")
+	router.HandleFunc("/api/dishes/capacity/{id}",
+		middleware.ValidateJWT(controllers.GetDishCapacity)).Methods("GET")
+	router.HandleFunc("/api/dishes/label/{id}",
+		middleware.ValidateJWT(controllers.GetDishLabel)).Methods("GET")
+	router.HandleFunc("/api/dishes/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreateDish))).Methods("POST")
+	router.HandleFunc("/api/dishes/update/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.UpdateDish))).Methods("PUT")
+	router.HandleFunc("/api/dishes/delete/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteDish))).Methods("DELETE")
+
+	// Списания
+	router.HandleFunc("/api/write-offs/all",
+		middleware.ValidateJWT(controllers.GetWriteOffs)).Methods("GET")
+	router.HandleFunc("/api/write-offs/get/{id}",
+		middleware.ValidateJWT(controllers.GetWriteOff)).Methods("GET")
+	router.HandleFunc("/api/write-offs/add",
+		middleware.ValidateJWT(controllers.CreateWriteOff)).Methods("POST")
+	router.HandleFunc("/api/write-offs/approve/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.ApproveWriteOff))).Methods("PUT")
+	router.HandleFunc("/api/write-offs/reject/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.RejectWriteOff))).Methods("PUT")
+
+	// Выпуск полуфабрикатов
+	router.HandleFunc("/api/production/all",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetProductionRuns))).Methods("GET")
+	router.HandleFunc("/api/production/get/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetProductionRun))).Methods("GET")
+	router.HandleFunc("/api/production/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreateProductionRun))).Methods("POST")
+
+	// Меню
+	router.HandleFunc("/api/menu/all",
+		middleware.ValidateJWT(controllers.GetMenu)).Methods("GET")
+	router.HandleFunc("/api/menu/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreateMenuItem))).Methods("POST")
+	router.HandleFunc("/api/menu/update/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.UpdateMenuItem))).Methods("PUT")
+	router.HandleFunc("/api/menu/availability/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.SetMenuAvailability))).Methods("PUT")
+	router.HandleFunc("/api/menu/delete/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteMenuItem))).Methods("DELETE")
+
+	// Столики
+	router.HandleFunc("/api/tables/all",
+		middleware.ValidateJWT(controllers.GetTables)).Methods("GET")
+	router.HandleFunc("/api/tables/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreateTable))).Methods("POST")
+	router.HandleFunc("/api/tables/update/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.UpdateTable))).Methods("PUT")
+	router.HandleFunc("/api/tables/status/{id}",
+		middleware.ValidateJWT(controllers.SetTableStatus)).Methods("PUT")
+	router.HandleFunc("/api/tables/delete/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteTable))).Methods("DELETE")
+
+	// Заказы
+	router.HandleFunc("/api/orders/all",
+		middleware.ValidateJWT(controllers.GetOrders)).Methods("GET")
+	router.HandleFunc("/api/orders/get/{id}",
+		middleware.ValidateJWT(controllers.GetOrder)).Methods("GET")
+	router.HandleFunc("/api/orders/add",
+		middleware.ValidateJWT(controllers.CreateOrder)).Methods("POST")
+	router.HandleFunc("/api/orders/update/{id}",
+		middleware.ValidateJWT(controllers.UpdateOrder)).Methods("PUT")
+	router.HandleFunc("/api/orders/send/{id}",
+		middleware.ValidateJWT(controllers.SendOrderToKitchen)).Methods("POST")
+	router.HandleFunc("/api/orders/serve/{id}",
+		middleware.ValidateJWT(controllers.ServeOrder)).Methods("POST")
+	router.HandleFunc("/api/orders/pay/{id}",
+		middleware.ValidateJWT(controllers.PayOrder)).Methods("POST")
+	router.HandleFunc("/api/orders/cancel/{id}",
+		middleware.ValidateJWT(controllers.CancelOrder)).Methods("POST")
+	router.HandleFunc("/api/orders/redeem/{id}",
+		middleware.ValidateJWT(controllers.RedeemOrderPoints)).Methods("POST")
+
+	// Постоянные гости и бонусные баллы
+	router.HandleFunc("/api/customers/all",
+		middleware.ValidateJWT(controllers.GetCustomers)).Methods("GET")
+	router.HandleFunc("/api/customers/get/{id}",
+		middleware.ValidateJWT(controllers.GetCustomer)).Methods("GET")
+	router.HandleFunc("/api/customers/history/{id}",
+		middleware.ValidateJWT(controllers.GetCustomerHistory)).Methods("GET")
+	router.HandleFunc("/api/customers/add",
+		middleware.ValidateJWT(controllers.CreateCustomer)).Methods("POST")
+	router.HandleFunc("/api/customers/update/{id}",
+		middleware.ValidateJWT(controllers.UpdateCustomer)).Methods("PUT")
+	router.HandleFunc("/api/customers/delete/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteCustomer))).Methods("DELETE")
+	router.HandleFunc("/api/loyalty/rules/all",
+		middleware.ValidateJWT(controllers.GetLoyaltyRules)).Methods("GET")
+	router.HandleFunc("/api/loyalty/rules/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreateLoyaltyRule))).Methods("POST")
+	router.HandleFunc("/api/loyalty/rules/update/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.UpdateLoyaltyRule))).Methods("PUT")
+	router.HandleFunc("/api/loyalty/rules/delete/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeleteLoyaltyRule))).Methods("DELETE")
+
+	// Прайс-листы, акции и расчёт корзины
+	router.HandleFunc("/api/price-lists/all",
+		middleware.ValidateJWT(controllers.GetPriceLists)).Methods("GET")
+	router.HandleFunc("/api/price-lists/get/{id}",
+		middleware.ValidateJWT(controllers.GetPriceList)).Methods("GET")
+	router.HandleFunc("/api/price-lists/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreatePriceList))).Methods("POST")
+	router.HandleFunc("/api/price-lists/update/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.UpdatePriceList))).Methods("PUT")
+	router.HandleFunc("/api/price-lists/delete/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeletePriceList))).Methods("DELETE")
+	router.HandleFunc("/api/price-lists/items/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.SetPriceListItem))).Methods("PUT")
+	router.HandleFunc("/api/price-lists/items/{id}/{product_id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeletePriceListItem))).Methods("DELETE")
+	router.HandleFunc("/api/promotions/all",
+		middleware.ValidateJWT(controllers.GetPromotions)).Methods("GET")
+	router.HandleFunc("/api/promotions/get/{id}",
+		middleware.ValidateJWT(controllers.GetPromotion)).Methods("GET")
+	router.HandleFunc("/api/promotions/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreatePromotion))).Methods("POST")
+	router.HandleFunc("/api/promotions/update/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.UpdatePromotion))).Methods("PUT")
+	router.HandleFunc("/api/promotions/delete/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.DeletePromotion))).Methods("DELETE")
+	router.HandleFunc("/api/pricing/basket",
+		middleware.ValidateJWT(controllers.PriceBasket)).Methods("POST")
+
+	// Очередь кухни
+	router.HandleFunc("/api/kitchen/queue",
+		middleware.ValidateJWT(controllers.GetKitchenQueue)).Methods("GET")
+	router.HandleFunc("/api/kitchen/stream",
+		middleware.TokenFromQuery(middleware.ValidateJWT(controllers.KitchenStream))).Methods("GET")
+	router.HandleFunc("/api/kitchen/start/{id}",
+		middleware.ValidateJWT(controllers.StartKitchenItem)).Methods("POST")
+	router.HandleFunc("/api/kitchen/complete/{id}",
+		middleware.ValidateJWT(controllers.CompleteKitchenItem)).Methods("POST")
+
+	// Резервы товара
+	router.HandleFunc("/api/reservations/all",
+		middleware.ValidateJWT(controllers.GetReservations)).Methods("GET")
+	router.HandleFunc("/api/reservations/add",
+		middleware.ValidateJWT(controllers.CreateReservation)).Methods("POST")
+	router.HandleFunc("/api/reservations/release/{id}",
+		middleware.ValidateJWT(controllers.ReleaseReservation)).Methods("POST")
+	router.HandleFunc("/api/reservations/release/{reference_type}/{reference_id}",
+		middleware.ValidateJWT(controllers.ReleaseReservationsByReference)).Methods("POST")
+	router.HandleFunc("/api/reservations/fulfil/{id}",
+		middleware.ValidateJWT(controllers.FulfilReservation)).Methods("POST")
+
+	// Инвентаризации
+	router.HandleFunc("/api/stocktakes/all",
+		middleware.ValidateJWT(controllers.GetStocktakes)).Methods("GET")
+	router.HandleFunc("/api/stocktakes/get/{id}",
+		middleware.ValidateJWT(controllers.GetStocktake)).Methods("GET")
+	router.HandleFunc("/api/stocktakes/add",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CreateStocktake))).Methods("POST")
+	router.HandleFunc("/api/stocktakes/count/{id}",
+		middleware.ValidateJWT(controllers.AddStocktakeCount)).Methods("POST")
+	router.HandleFunc("/api/stocktakes/approve/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.ApproveStocktake))).Methods("POST")
+	router.HandleFunc("/api/stocktakes/close/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CloseStocktake))).Methods("POST")
+	router.HandleFunc("/api/stocktakes/cancel/{id}",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.CancelStocktake))).Methods("POST")
+
+	// Отчёты
+	router.HandleFunc("/api/reports/valuation",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetValuationReport))).Methods("GET")
+	router.HandleFunc("/api/reports/waste",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetWasteReport))).Methods("GET")
+	router.HandleFunc("/api/reports/compliance",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetComplianceReport))).Methods("GET")
+	router.HandleFunc("/api/reports/stockout",
+		middleware.ValidateJWT(middleware.RoleCheck("admin", "manager")(controllers.GetStockoutReport))).Methods("GET")
 
 }
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+)
+
+// SuggestedOrderQuantity рассчитывает количество к заказу, чтобы довести остаток
+// до целевого уровня (или хотя бы до точки заказа, если целевой уровень не задан)
+func SuggestedOrderQuantity(p models.Product) int {
+	target := p.TargetLevel
+	if target < p.ReorderPoint {
+		target = p.ReorderPoint
+	}
+	if p.Quantity >= target {
+		return 0
+	}
+	return target - p.Quantity
+}
+
+// CheckStockAlerts сверяет остатки продуктов с их порогами.
+// Оповещение создаётся один раз при пересечении порога и закрывается, когда остаток
+// возвращается выше него, поэтому повторные проверки не плодят дубликатов.
+func CheckStockAlerts() error {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT id, quantity, min_level, reorder_point FROM products")
+	if err != nil {
+		return err
+	}
+	var products []models.Product
+	for rows.Next() {
+		var p models.Product
+		if err := rows.Scan(&p.ID, &p.Quantity, &p.MinLevel, &p.ReorderPoint); err != nil {
+			rows.Close()
+			return err
+		}
+		products = append(products, p)
+	}
+	rows.Close()
+
+	for _, p := range products {
+		checks := []struct {
+			alertType string
+			threshold int
+			crossed   bool
+		}{
+			{models.AlertTypeReorder, p.ReorderPoint, p.ReorderPoint > 0 && p.Quantity <= p.ReorderPoint},
+			{models.AlertTypeMinimum, p.MinLevel, p.MinLevel > 0 && p.Quantity < p.MinLevel},
+		}
+
+		for _, c := range checks {
+			var openAlerts int
+			err := tx.QueryRow(`
+				SELECT COUNT(*) FROM stock_alerts
+				WHERE product_id = ? AND alert_type = ? AND resolved_at IS NULL
+			`, p.ID, c.alertType).Scan(&openAlerts)
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case c.crossed && openAlerts == 0:
+				_, err = tx.Exec(`
+					INSERT INTO stock_alerts (product_id, alert_type, quantity, threshold)
+					VALUES (?, ?, ?, ?)
+				`, p.ID, c.alertType, p.Quantity, c.threshold)
+			case !c.crossed && openAlerts > 0:
+				_, err = tx.Exec(`
+					UPDATE stock_alerts SET resolved_at = CURRENT_TIMESTAMP
+					WHERE product_id = ? AND alert_type = ? AND resolved_at IS NULL
+				`, p.ID, c.alertType)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// StartStockAlertChecker запускает фоновую проверку остатков с заданным интервалом
+func StartStockAlertChecker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := CheckStockAlerts(); err != nil {
+				log.Println("Stock alert check failed:", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
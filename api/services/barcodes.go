@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Типы штрихкодов
+const (
+	BarcodeEAN8    = "EAN8"
+	BarcodeEAN13   = "EAN13"
+	BarcodeUPCA    = "UPCA"
+	BarcodeCode128 = "CODE128"
+)
+
+// Длина префикса весового штрихкода: «2» + цифра + пятизначный код товара
+const WeightedPrefixLength = 7
+
+var (
+	ErrInvalidBarcode  = errors.New("invalid barcode")
+	ErrInvalidChecksum = errors.New("invalid barcode checksum")
+)
+
+// DetectBarcodeType определяет тип штрихкода по его содержимому
+func DetectBarcodeType(code string) string {
+	if isDigits(code) {
+		switch len(code) {
+		case 8:
+			return BarcodeEAN8
+		case 12:
+			return BarcodeUPCA
+		case 13:
+			return BarcodeEAN13
+		}
+	}
+	return BarcodeCode128
+}
+
+// ValidateBarcode проверяет формат и контрольную цифру штрихкода заданного типа.
+// Весовой штрихкод регистрируется префиксом из семи цифр, начинающимся с «2».
+func ValidateBarcode(code, barcodeType string, weighted bool) error {
+	if weighted {
+		if barcodeType != BarcodeEAN13 || len(code) != WeightedPrefixLength || !isDigits(code) || code[0] != '2' {
+			return ErrInvalidBarcode
+		}
+		return nil
+	}
+
+	switch barcodeType {
+	case BarcodeEAN8, BarcodeUPCA, BarcodeEAN13:
+		length := map[string]int{BarcodeEAN8: 8, BarcodeUPCA: 12, BarcodeEAN13: 13}[barcodeType]
+		if len(code) != length || !isDigits(code) {
+			return ErrInvalidBarcode
+		}
+		if !validGTINChecksum(code) {
+			return ErrInvalidChecksum
+		}
+	case BarcodeCode128:
+		if len(code) == 0 || len(code) > 48 {
+			return ErrInvalidBarcode
+		}
+		for _, c := range code {
+			if c < 32 || c > 126 {
+				return ErrInvalidBarcode
+			}
+		}
+	default:
+		return ErrInvalidBarcode
+	}
+	return nil
+}
+
+// ParseWeightedBarcode разбирает весовой EAN-13 (префикс 20–29): возвращает префикс
+// товара и вес в граммах. ok = false, если код не является весовым.
+func ParseWeightedBarcode(code string) (prefix string, weight int, ok bool) {
+	if len(code) != 13 || !isDigits(code) || code[0] != '2' || !validGTINChecksum(code) {
+		return "", 0, false
+	}
+	weight, _ = strconv.Atoi(code[WeightedPrefixLength:12])
+	return code[:WeightedPrefixLength], weight, true
+}
+
+// validGTINChecksum проверяет контрольную цифру EAN-8, EAN-13 и UPC-A (модуль 10)
+func validGTINChecksum(code string) bool {
+	sum := 0
+	// Веса 3 и 1 чередуются справа налево, начиная с цифры перед контрольной
+	for i := len(code) - 2; i >= 0; i-- {
+		digit := int(code[i] - '0')
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	return (10-sum%10)%10 == int(code[len(code)-1]-'0')
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, c := range value {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
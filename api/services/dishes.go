@@ -0,0 +1,101 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+var ErrDishNotFound = errors.New("dish not found")
+
+// LoadDish загружает блюдо с рецептурой и рассчитывает себестоимость порции
+// по текущей себестоимости продуктов
+func LoadDish(q Querier, id int) (models.Dish, error) {
+	var d models.Dish
+	err := q.QueryRow(`
+		SELECT id, name, description, yield, portion_size, portion_unit_id FROM dishes WHERE id = ?
+	`, id).Scan(&d.ID, &d.Name, &d.Description, &d.Yield, &d.PortionSize, &d.PortionUnitID)
+	if err == sql.ErrNoRows {
+		return d, ErrDishNotFound
+	}
+	if err != nil {
+		return d, err
+	}
+
+	rows, err := q.Query(`
+		SELECT i.id, i.product_id, p.name, i.quantity, i.unit_id, i.base_quantity,
+			CASE WHEN u.factor > 0 THEN p.cost_price / u.factor ELSE 0 END
+		FROM dish_ingredients i
+		JOIN products p ON i.product_id = p.id
+		JOIN units u ON p.unit_id = u.id
+		WHERE i.dish_id = ?
+		ORDER BY i.id
+	`, id)
+	if err != nil {
+		return d, err
+	}
+	defer rows.Close()
+
+	var cost float64
+	d.Ingredients = []models.DishIngredient{}
+	for rows.Next() {
+		var i models.DishIngredient
+		var baseCost float64
+		if err := rows.Scan(&i.ID, &i.ProductID, &i.ProductName, &i.Quantity, &i.UnitID, &i.BaseQuantity, &baseCost); err != nil {
+			return d, err
+		}
+		i.PortionQuantity = i.BaseQuantity / float64(d.Yield)
+		portionCost := utils.RoundMoney(i.PortionQuantity * baseCost)
+		i.PortionCost = &portionCost
+		cost += i.PortionQuantity * baseCost
+		d.Ingredients = append(d.Ingredients, i)
+	}
+	if err := rows.Err(); err != nil {
+		return d, err
+	}
+
+	cost = utils.RoundMoney(cost)
+	d.CostPerPortion = &cost
+	d.Currency = models.DefaultCurrency
+	return d, nil
+}
+
+// DishCapacity рассчитывает, сколько порций блюда можно приготовить из свободного
+// (не зарезервированного) остатка, и определяет ограничивающий ингредиент
+func DishCapacity(q Querier, d models.Dish) (models.DishCapacity, error) {
+	capacity := models.DishCapacity{
+		DishID:      d.ID,
+		DishName:    d.Name,
+		Ingredients: []models.IngredientCapacity{},
+	}
+
+	for _, i := range d.Ingredients {
+		available, err := AvailableQuantity(q, i.ProductID)
+		if err != nil {
+			return capacity, err
+		}
+		ic := models.IngredientCapacity{
+			ProductID:       i.ProductID,
+			ProductName:     i.ProductName,
+			Available:       available,
+			PortionQuantity: i.PortionQuantity,
+		}
+		if available > 0 && i.PortionQuantity > 0 {
+			ic.Portions = int(math.Floor(float64(available)/i.PortionQuantity + 1e-9))
+		}
+		capacity.Ingredients = append(capacity.Ingredients, ic)
+
+		if capacity.Limiting == nil || ic.Portions < capacity.Limiting.Portions {
+			limiting := ic
+			capacity.Limiting = &limiting
+		}
+	}
+
+	if capacity.Limiting != nil {
+		capacity.Portions = capacity.Limiting.Portions
+	}
+	return capacity, nil
+}
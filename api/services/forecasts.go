@@ -0,0 +1,195 @@
+package services
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// ForecastHorizonDays — как далеко вперёд ищется дата исчерпания остатка
+const ForecastHorizonDays = 365
+
+// dailyUsage возвращает потребление продуктов по суткам за период (now − lookbackDays, now];
+// последний элемент — последние сутки. Потребление — расход за вычетом возвратов; списания
+// (порча, потери) спросом не считаются. На этом расчёте построены и прогноз исчерпания,
+// и средний расход списка закупки (AverageDailyUsage).
+func dailyUsage(q Querier, lookbackDays int, now time.Time) (map[int][]float64, error) {
+	rows, err := q.Query(`
+		SELECT product_id, quantity, created_at
+		FROM stock_movements
+		WHERE movement_type IN (?, ?) AND created_at > ? AND created_at <= ?
+	`, models.MovementIssue, models.MovementReturn,
+		utils.FormatDBTime(now.AddDate(0, 0, -lookbackDays)), utils.FormatDBTime(now))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := make(map[int][]float64)
+	for rows.Next() {
+		var productID, quantity int
+		var createdAt string
+		if err := rows.Scan(&productID, &quantity, &createdAt); err != nil {
+			return nil, err
+		}
+		t, err := utils.ParseDateTime(createdAt)
+		if err != nil {
+			continue
+		}
+		day := lookbackDays - 1 - int(now.Sub(t).Hours()/24)
+		if day < 0 || day >= lookbackDays {
+			continue
+		}
+		if usage[productID] == nil {
+			usage[productID] = make([]float64, lookbackDays)
+		}
+		usage[productID][day] -= float64(quantity)
+	}
+	return usage, rows.Err()
+}
+
+// linearTrend подбирает прямую usage(x) = intercept + slope·x методом наименьших квадратов
+func linearTrend(series []float64) (intercept, slope float64) {
+	n := float64(len(series))
+	if n == 0 {
+		return 0, 0
+	}
+	var sumX, sumY float64
+	for x, y := range series {
+		sumX += float64(x)
+		sumY += y
+	}
+	meanX, meanY := sumX/n, sumY/n
+	var cov, varX float64
+	for x, y := range series {
+		cov += (float64(x) - meanX) * (y - meanY)
+		varX += (float64(x) - meanX) * (float64(x) - meanX)
+	}
+	if varX > 0 {
+		slope = cov / varX
+	}
+	return meanY - slope*meanX, slope
+}
+
+// ForecastConsumption рассчитывает прогноз по дневному расходу series
+// и свободному остатку available
+func ForecastConsumption(series []float64, available int, now time.Time) models.ConsumptionForecast {
+	f := models.ConsumptionForecast{
+		Available:    available,
+		LookbackDays: len(series),
+		ComputedAt:   now.UTC().Format(time.RFC3339),
+	}
+	if len(series) == 0 {
+		return f
+	}
+
+	var total float64
+	for _, y := range series {
+		total += y
+	}
+	average := math.Max(total/float64(len(series)), 0)
+	intercept, slope := linearTrend(series)
+	last := float64(len(series) - 1)
+
+	f.AverageDailyUsage = math.Round(average*100) / 100
+	f.TrendSlope = math.Round(slope*100) / 100
+	f.TrendDailyUsage = math.Round(math.Max(intercept+slope*last, 0)*100) / 100
+
+	if average == 0 {
+		// Продукт не расходуется — остатка хватит на неопределённый срок
+		return f
+	}
+	days := math.Round(math.Max(float64(available), 0)/average*10) / 10
+	f.DaysOfSupply = &days
+
+	// Остаток расходуется по тренду, пока не закончится или не кончится горизонт
+	remaining := float64(available)
+	for day := 0; day <= ForecastHorizonDays; day++ {
+		if remaining <= 0 {
+			date := now.AddDate(0, 0, day).Format("2006-01-02")
+			f.StockoutDate = &date
+			break
+		}
+		remaining -= math.Max(intercept+slope*(last+float64(day+1)), 0)
+	}
+	return f
+}
+
+// RecalculateForecasts пересчитывает прогнозы расхода всех продуктов
+// по истории за lookbackDays дней
+func RecalculateForecasts(lookbackDays int) error {
+	now := time.Now().UTC()
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	usage, err := dailyUsage(tx, lookbackDays, now)
+	if err != nil {
+		return err
+	}
+	reserved, err := ReservedQuantities(tx)
+	if err != nil {
+		return err
+	}
+	blocked, err := BlockedQuantities(tx)
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query("SELECT id, quantity FROM products")
+	if err != nil {
+		return err
+	}
+	available := make(map[int]int)
+	for rows.Next() {
+		var id, quantity int
+		if err := rows.Scan(&id, &quantity); err != nil {
+			rows.Close()
+			return err
+		}
+		available[id] = quantity - reserved[id] - blocked[id]
+	}
+	rows.Close()
+
+	if _, err := tx.Exec("DELETE FROM product_forecasts"); err != nil {
+		return err
+	}
+	for productID, quantity := range available {
+		series := usage[productID]
+		if series == nil {
+			series = make([]float64, lookbackDays)
+		}
+		f := ForecastConsumption(series, quantity, now)
+		_, err := tx.Exec(`
+			INSERT INTO product_forecasts (product_id, available, lookback_days, average_daily_usage, trend_daily_usage,
+				trend_slope, days_of_supply, stockout_date, computed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, productID, f.Available, f.LookbackDays, f.AverageDailyUsage, f.TrendDailyUsage,
+			f.TrendSlope, f.DaysOfSupply, f.StockoutDate, utils.FormatDBTime(now))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// StartForecastRecalculation запускает периодический пересчёт прогнозов расхода
+func StartForecastRecalculation(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := RecalculateForecasts(DefaultLookbackDays); err != nil {
+				log.Println("Forecast recalculation failed:", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
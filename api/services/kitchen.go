@@ -0,0 +1,44 @@
+package services
+
+import (
+	"sync"
+
+	"wuwunchik.github.io/api/models"
+)
+
+// kitchenBroker рассылает события кухни всем подписчикам (экранам кухни)
+type kitchenBroker struct {
+	mu          sync.Mutex
+	subscribers map[chan models.KitchenEvent]struct{}
+}
+
+var kitchenEvents = &kitchenBroker{subscribers: make(map[chan models.KitchenEvent]struct{})}
+
+// SubscribeKitchen подписывает на события кухни; возвращённую функцию нужно вызвать при отключении
+func SubscribeKitchen() (<-chan models.KitchenEvent, func()) {
+	ch := make(chan models.KitchenEvent, 32)
+	kitchenEvents.mu.Lock()
+	kitchenEvents.subscribers[ch] = struct{}{}
+	kitchenEvents.mu.Unlock()
+
+	return ch, func() {
+		kitchenEvents.mu.Lock()
+		delete(kitchenEvents.subscribers, ch)
+		kitchenEvents.mu.Unlock()
+	}
+}
+
+// PublishKitchen отправляет событие подписчикам. Медленный подписчик с заполненным
+// буфером пропускает событие, чтобы не задерживать обработку заказов.
+func PublishKitchen(events ...models.KitchenEvent) {
+	kitchenEvents.mu.Lock()
+	defer kitchenEvents.mu.Unlock()
+	for ch := range kitchenEvents.subscribers {
+		for _, e := range events {
+			select {
+			case ch <- e:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"database/sql"
+
+	"wuwunchik.github.io/api/models"
+)
+
+// UnassignedQuantity возвращает остаток продукта, не распределённый по местам хранения
+func UnassignedQuantity(q Querier, productID int) (int, error) {
+	var unassigned int
+	err := q.QueryRow(`
+		SELECT p.quantity - COALESCE((SELECT SUM(ls.quantity) FROM location_stock ls WHERE ls.product_id = p.id), 0)
+		FROM products p WHERE p.id = ?
+	`, productID).Scan(&unassigned)
+	if err == sql.ErrNoRows {
+		return 0, ErrProductNotFound
+	}
+	return unassigned, err
+}
+
+// changeLocationStock изменяет остаток продукта в месте хранения на delta
+func changeLocationStock(tx *sql.Tx, locationID, productID, delta int) error {
+	var exists int
+	if err := tx.QueryRow("SELECT COUNT(*) FROM locations WHERE id = ?", locationID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrLocationNotFound
+	}
+
+	if delta < 0 {
+		result, err := tx.Exec(`
+			UPDATE location_stock SET quantity = quantity + ?
+			WHERE location_id = ? AND product_id = ? AND quantity + ? >= 0
+		`, delta, locationID, productID, delta)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return ErrInsufficientLocationStock
+		}
+		return nil
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO location_stock (location_id, product_id, quantity) VALUES (?, ?, ?)
+		ON CONFLICT (location_id, product_id) DO UPDATE SET quantity = quantity + excluded.quantity
+	`, locationID, productID, delta)
+	return err
+}
+
+// PostIssue проводит расход m.Quantity (отрицательное количество). Если место хранения
+// не указано, сначала расходуется нераспределённый остаток, а затем места хранения
+// в порядке их id; на каждую часть записывается отдельное движение.
+func PostIssue(tx *sql.Tx, m models.StockMovement) ([]models.StockMovement, error) {
+	if m.LocationID != 0 {
+		if err := PostMovement(tx, &m); err != nil {
+			return nil, err
+		}
+		return []models.StockMovement{m}, nil
+	}
+
+	remaining := -m.Quantity
+	unassigned, err := UnassignedQuantity(tx, m.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	draws := []models.LocationStock{}
+	if unassigned > 0 {
+		draws = append(draws, models.LocationStock{Quantity: min(unassigned, remaining)})
+		remaining -= draws[0].Quantity
+	}
+	if remaining > 0 {
+		rows, err := tx.Query(`
+			SELECT location_id, quantity FROM location_stock
+			WHERE product_id = ? AND quantity > 0
+			ORDER BY location_id
+		`, m.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() && remaining > 0 {
+			var ls models.LocationStock
+			if err := rows.Scan(&ls.LocationID, &ls.Quantity); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			ls.Quantity = min(ls.Quantity, remaining)
+			draws = append(draws, ls)
+			remaining -= ls.Quantity
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if remaining > 0 {
+		return nil, ErrInsufficientStock
+	}
+
+	var movements []models.StockMovement
+	for _, d := range draws {
+		part := m
+		part.LocationID = d.LocationID
+		part.Quantity = -d.Quantity
+		if err := PostMovement(tx, &part); err != nil {
+			return nil, err
+		}
+		movements = append(movements, part)
+	}
+	return movements, nil
+}
+
+// TransferStock атомарно перемещает продукт между местами хранения в рамках транзакции tx.
+// Нулевое место хранения означает нераспределённый остаток.
+func TransferStock(tx *sql.Tx, t *models.StockTransfer) error {
+	if t.BaseQuantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO stock_transfers (product_id, from_location_id, to_location_id, quantity, unit_id, base_quantity, note, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, t.ProductID, t.FromLocationID, t.ToLocationID, t.Quantity, t.UnitID, t.BaseQuantity, t.Note, t.CreatedBy)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	t.ID = int(id)
+
+	for _, m := range []models.StockMovement{
+		{LocationID: t.FromLocationID, Quantity: -t.BaseQuantity},
+		{LocationID: t.ToLocationID, Quantity: t.BaseQuantity},
+	} {
+		m.ProductID = t.ProductID
+		m.MovementType = models.MovementTransfer
+		m.ReferenceType = "transfer"
+		m.ReferenceID = t.ID
+		m.Note = t.Note
+		m.CreatedBy = t.CreatedBy
+		if err := PostMovement(tx, &m); err != nil {
+			return err
+		}
+	}
+
+	return tx.QueryRow("SELECT created_at FROM stock_transfers WHERE id = ?", t.ID).Scan(&t.CreatedAt)
+}
+
+// LocationStock возвращает остатки продуктов по местам хранения, сгруппированные по продукту
+func LocationStock(q Querier) (map[int][]models.LocationStock, error) {
+	paths, err := LocationPaths(q)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := q.Query(`
+		SELECT location_id, product_id, quantity FROM location_stock
+		WHERE quantity <> 0
+		ORDER BY location_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stock := make(map[int][]models.LocationStock)
+	for rows.Next() {
+		var ls models.LocationStock
+		if err := rows.Scan(&ls.LocationID, &ls.ProductID, &ls.Quantity); err != nil {
+			return nil, err
+		}
+		ls.LocationPath = paths[ls.LocationID]
+		stock[ls.ProductID] = append(stock[ls.ProductID], ls)
+	}
+	return stock, rows.Err()
+}
+
+// LocationPaths возвращает полные пути мест хранения вида «Холодильная камера / Полка 2»
+func LocationPaths(q Querier) (map[int]string, error) {
+	rows, err := q.Query(`
+		WITH RECURSIVE tree (id, path) AS (
+			SELECT id, name FROM locations WHERE parent_id IS NULL
+			UNION ALL
+			SELECT l.id, tree.path || ' / ' || l.name FROM locations l JOIN tree ON l.parent_id = tree.id
+		)
+		SELECT id, path FROM tree
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	paths := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var path string
+		if err := rows.Scan(&id, &path); err != nil {
+			return nil, err
+		}
+		paths[id] = path
+	}
+	return paths, rows.Err()
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+)
+
+// LotReasonExpired — причина автоматической блокировки партии
+const LotReasonExpired = "expired"
+
+// FindOrCreateLot возвращает партию продукта с указанным номером, создавая её при необходимости.
+// Если номер не указан, партии группируются по сроку годности.
+func FindOrCreateLot(tx *sql.Tx, productID int, lotNumber string, expiryDate *string) (int, error) {
+	if lotNumber == "" {
+		if expiryDate == nil {
+			return 0, nil
+		}
+		lotNumber = "EXP-" + *expiryDate
+	}
+
+	var id int
+	err := tx.QueryRow("SELECT id FROM lots WHERE product_id = ? AND lot_number = ?", productID, lotNumber).Scan(&id)
+	if err == nil {
+		return id, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+
+	// Партия с истёкшим сроком сразу поступает заблокированной
+	blocked, reason := 0, ""
+	if expiryDate != nil && *expiryDate < time.Now().Format("2006-01-02") {
+		blocked, reason = 1, LotReasonExpired
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO lots (product_id, lot_number, expiry_date, blocked, blocked_reason) VALUES (?, ?, ?, ?, ?)
+	`, productID, lotNumber, expiryDate, blocked, reason)
+	if err != nil {
+		return 0, err
+	}
+	lotID, _ := result.LastInsertId()
+	return int(lotID), nil
+}
+
+// IssueStock списывает количество issue.Quantity (в базовых единицах) продукта.
+// Сначала расходуются партии, выбранные вручную, затем — по правилу FEFO
+// (первой истекает — первой расходуется), и только потом остаток вне партий.
+// Заблокированные и просроченные партии не расходуются. Без места хранения
+// расход распределяется по местам хранения так, как описано в PostIssue.
+func IssueStock(tx *sql.Tx, issue models.StockMovement, allocations []models.LotAllocation) ([]models.StockMovement, error) {
+	if issue.Quantity <= 0 {
+		return nil, ErrInvalidQuantity
+	}
+	remaining := issue.Quantity
+	today := time.Now().Format("2006-01-02")
+
+	var movements []models.StockMovement
+	post := func(lotID, quantity int) error {
+		m := issue
+		m.LotID = lotID
+		m.Quantity = -quantity
+		posted, err := PostIssue(tx, m)
+		if err != nil {
+			return err
+		}
+		movements = append(movements, posted...)
+		remaining -= quantity
+		return nil
+	}
+
+	for _, a := range allocations {
+		if a.Quantity <= 0 || a.Quantity > remaining {
+			return nil, ErrInvalidQuantity
+		}
+		var blocked int
+		var expiry sql.NullString
+		err := tx.QueryRow("SELECT blocked, expiry_date FROM lots WHERE id = ? AND product_id = ?", a.LotID, issue.ProductID).
+			Scan(&blocked, &expiry)
+		if err == sql.ErrNoRows {
+			return nil, ErrLotNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if blocked != 0 || (expiry.Valid && expiry.String < today) {
+			return nil, ErrLotBlocked
+		}
+		if err := post(a.LotID, a.Quantity); err != nil {
+			return nil, err
+		}
+	}
+
+	if remaining > 0 {
+		rows, err := tx.Query(`
+			SELECT id, quantity FROM lots
+			WHERE product_id = ? AND quantity > 0 AND blocked = 0
+				AND (expiry_date IS NULL OR expiry_date >= ?)
+			ORDER BY expiry_date IS NULL, expiry_date, received_at, id
+		`, issue.ProductID, today)
+		if err != nil {
+			return nil, err
+		}
+		var lots []models.LotAllocation
+		for rows.Next() {
+			var l models.LotAllocation
+			if err := rows.Scan(&l.LotID, &l.Quantity); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			lots = append(lots, l)
+		}
+		rows.Close()
+
+		for _, l := range lots {
+			if remaining == 0 {
+				break
+			}
+			quantity := l.Quantity
+			if quantity > remaining {
+				quantity = remaining
+			}
+			if err := post(l.LotID, quantity); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if remaining > 0 {
+		// Остаток продукта, не распределённый по партиям
+		var untracked int
+		err := tx.QueryRow(`
+			SELECT p.quantity - COALESCE((SELECT SUM(l.quantity) FROM lots l WHERE l.product_id = p.id), 0)
+			FROM products p WHERE p.id = ?
+		`, issue.ProductID).Scan(&untracked)
+		if err == sql.ErrNoRows {
+			return nil, ErrProductNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if untracked < remaining {
+			return nil, ErrInsufficientStock
+		}
+		if err := post(0, remaining); err != nil {
+			return nil, err
+		}
+	}
+
+	return movements, nil
+}
+
+// unusableLot — условие партии, которую нельзя расходовать: заблокированной или
+// просроченной на дату, переданную параметром
+const unusableLot = `(blocked <> 0 OR (expiry_date IS NOT NULL AND expiry_date < ?))`
+
+// BlockedQuantities возвращает количество продуктов в партиях, которые нельзя расходовать
+func BlockedQuantities(q Querier) (map[int]int, error) {
+	rows, err := q.Query(`
+		SELECT product_id, SUM(quantity) FROM lots
+		WHERE quantity > 0 AND `+unusableLot+`
+		GROUP BY product_id
+	`, time.Now().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := make(map[int]int)
+	for rows.Next() {
+		var productID, quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		blocked[productID] = quantity
+	}
+	return blocked, rows.Err()
+}
+
+// BlockExpiredLots блокирует партии с истёкшим сроком годности и возвращает их число
+func BlockExpiredLots() (int64, error) {
+	result, err := database.DB.Exec(`
+		UPDATE lots SET blocked = 1, blocked_reason = ?
+		WHERE blocked = 0 AND expiry_date IS NOT NULL AND expiry_date < ?
+	`, LotReasonExpired, time.Now().Format("2006-01-02"))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// StartLotExpiryChecker запускает фоновую блокировку просроченных партий с заданным интервалом
+func StartLotExpiryChecker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if blocked, err := BlockExpiredLots(); err != nil {
+				log.Println("Lot expiry check failed:", err)
+			} else if blocked > 0 {
+				log.Printf("Blocked %d expired lots", blocked)
+			}
+			<-ticker.C
+		}
+	}()
+}
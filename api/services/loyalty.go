@@ -0,0 +1,230 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"os"
+	"strconv"
+
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// DefaultLoyaltyPointValue — скидка за один балл, если LOYALTY_POINT_VALUE не задан
+const DefaultLoyaltyPointValue = 1.0
+
+var (
+	ErrCustomerNotFound     = errors.New("customer not found")
+	ErrOrderNotFound        = errors.New("order not found")
+	ErrOrderHasNoCustomer   = errors.New("order has no customer")
+	ErrInsufficientPoints   = errors.New("insufficient loyalty points")
+	ErrPointsExceedTotal    = errors.New("points discount exceeds order total")
+	ErrOrderNotRedeemable   = errors.New("points can only be redeemed on unpaid orders")
+	ErrInvalidLoyaltyRule   = errors.New("spend_amount and points must be positive")
+	ErrLoyaltyRuleNotFound  = errors.New("loyalty rule not found")
+	ErrCustomerHasRedeemed  = errors.New("order customer cannot be changed while points are redeemed")
+	ErrNegativePointsAmount = errors.New("points must not be negative")
+)
+
+// LoyaltyPointValue возвращает скидку за один балл из переменной окружения LOYALTY_POINT_VALUE
+func LoyaltyPointValue() float64 {
+	if value := os.Getenv("LOYALTY_POINT_VALUE"); value != "" {
+		if v, err := strconv.ParseFloat(value, 64); err == nil && v > 0 {
+			return v
+		}
+	}
+	return DefaultLoyaltyPointValue
+}
+
+// addLoyaltyTransaction изменяет баланс гостя и записывает изменение в журнал
+func addLoyaltyTransaction(tx *sql.Tx, customerID int, orderID *int, points int, reason, createdBy string) error {
+	if points == 0 {
+		return nil
+	}
+	result, err := tx.Exec("UPDATE customers SET points = points + ? WHERE id = ? AND points + ? >= 0", points, customerID, points)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		var exists int
+		if err := tx.QueryRow("SELECT COUNT(*) FROM customers WHERE id = ?", customerID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			return ErrCustomerNotFound
+		}
+		return ErrInsufficientPoints
+	}
+	_, err = tx.Exec(`
+		INSERT INTO loyalty_transactions (customer_id, order_id, points, reason, created_by) VALUES (?, ?, ?, ?, ?)
+	`, customerID, orderID, points, reason, createdBy)
+	return err
+}
+
+// orderTotal возвращает сумму строк заказа
+func orderTotal(q Querier, orderID int) (float64, error) {
+	var total float64
+	err := q.QueryRow("SELECT COALESCE(SUM(quantity * price), 0) FROM order_items WHERE order_id = ?", orderID).Scan(&total)
+	return utils.RoundMoney(total), err
+}
+
+// RedeemPoints списывает баллы гостя в счёт скидки на неоплаченный заказ. Ранее списанные
+// по заказу баллы сначала возвращаются, поэтому повторный вызов заменяет скидку,
+// а points = 0 её снимает. Скидка не может превышать сумму заказа.
+func RedeemPoints(tx *sql.Tx, orderID, points int, username string) error {
+	if points < 0 {
+		return ErrNegativePointsAmount
+	}
+	var status string
+	var customerID *int
+	var redeemed int
+	err := tx.QueryRow("SELECT status, customer_id, points_redeemed FROM orders WHERE id = ?", orderID).
+		Scan(&status, &customerID, &redeemed)
+	if err == sql.ErrNoRows {
+		return ErrOrderNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status == models.OrderPaid || status == models.OrderCancelled {
+		return ErrOrderNotRedeemable
+	}
+	if customerID == nil {
+		return ErrOrderHasNoCustomer
+	}
+
+	total, err := orderTotal(tx, orderID)
+	if err != nil {
+		return err
+	}
+	discount := utils.RoundMoney(float64(points) * LoyaltyPointValue())
+	if discount > total {
+		return ErrPointsExceedTotal
+	}
+
+	if err := addLoyaltyTransaction(tx, *customerID, &orderID, redeemed, models.LoyaltyRefund, username); err != nil {
+		return err
+	}
+	if err := addLoyaltyTransaction(tx, *customerID, &orderID, -points, models.LoyaltyRedeem, username); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE orders SET points_redeemed = ?, points_discount = ? WHERE id = ?", points, discount, orderID)
+	return err
+}
+
+// RefundRedeemedPoints возвращает гостю баллы, списанные по отменённому заказу
+func RefundRedeemedPoints(tx *sql.Tx, orderID int, username string) error {
+	var customerID *int
+	var redeemed int
+	err := tx.QueryRow("SELECT customer_id, points_redeemed FROM orders WHERE id = ?", orderID).Scan(&customerID, &redeemed)
+	if err != nil {
+		return err
+	}
+	if customerID == nil || redeemed == 0 {
+		return nil
+	}
+	if err := addLoyaltyTransaction(tx, *customerID, &orderID, redeemed, models.LoyaltyRefund, username); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE orders SET points_redeemed = 0, points_discount = 0 WHERE id = ?", orderID)
+	return err
+}
+
+// OrderLoyaltyPoints рассчитывает баллы за заказ по активным правилам. Общие правила
+// начисляют баллы с суммы к оплате (за вычетом скидки), правила позиции меню —
+// с суммы её строк; MinOrderTotal сравнивается с суммой к оплате.
+func OrderLoyaltyPoints(q Querier, orderID int) (int, error) {
+	var due float64
+	err := q.QueryRow(`
+		SELECT COALESCE((SELECT SUM(quantity * price) FROM order_items WHERE order_id = o.id), 0) - o.points_discount
+		FROM orders o WHERE o.id = ?
+	`, orderID).Scan(&due)
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := q.Query(`
+		SELECT r.spend_amount, r.points, r.min_order_total, r.menu_item_id IS NOT NULL,
+			COALESCE((SELECT SUM(oi.quantity * oi.price) FROM order_items oi
+				WHERE oi.order_id = ? AND oi.menu_item_id = r.menu_item_id), 0)
+		FROM loyalty_rules r
+		WHERE r.active = 1
+	`, orderID)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	points := 0
+	for rows.Next() {
+		var spend, minTotal, itemsTotal float64
+		var rulePoints int
+		var forItem bool
+		if err := rows.Scan(&spend, &rulePoints, &minTotal, &forItem, &itemsTotal); err != nil {
+			return 0, err
+		}
+		if spend <= 0 || due < minTotal {
+			continue
+		}
+		base := due
+		if forItem {
+			base = itemsTotal
+		}
+		points += int(math.Floor(base/spend+1e-9)) * rulePoints
+	}
+	return points, rows.Err()
+}
+
+// AwardOrderPoints начисляет гостю баллы за оплаченный заказ
+func AwardOrderPoints(tx *sql.Tx, orderID int, username string) error {
+	var customerID *int
+	err := tx.QueryRow("SELECT customer_id FROM orders WHERE id = ?", orderID).Scan(&customerID)
+	if err != nil || customerID == nil {
+		return err
+	}
+	points, err := OrderLoyaltyPoints(tx, orderID)
+	if err != nil {
+		return err
+	}
+	if err := addLoyaltyTransaction(tx, *customerID, &orderID, points, models.LoyaltyEarn, username); err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE orders SET points_earned = ? WHERE id = ?", points, orderID)
+	return err
+}
+
+// LoadCustomer загружает гостя с балансом баллов
+func LoadCustomer(q Querier, id int) (models.Customer, error) {
+	var c models.Customer
+	err := q.QueryRow(`
+		SELECT id, name, phone, email, note, points, created_at FROM customers WHERE id = ?
+	`, id).Scan(&c.ID, &c.Name, &c.Phone, &c.Email, &c.Note, &c.Points, &c.CreatedAt)
+	if err == sql.ErrNoRows {
+		return c, ErrCustomerNotFound
+	}
+	return c, err
+}
+
+// CustomerTransactions возвращает журнал баллов гостя, начиная с последних изменений
+func CustomerTransactions(q Querier, customerID int) ([]models.LoyaltyTransaction, error) {
+	rows, err := q.Query(`
+		SELECT id, customer_id, order_id, points, reason, created_by, created_at
+		FROM loyalty_transactions WHERE customer_id = ?
+		ORDER BY created_at DESC, id DESC
+	`, customerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []models.LoyaltyTransaction{}
+	for rows.Next() {
+		var t models.LoyaltyTransaction
+		if err := rows.Scan(&t.ID, &t.CustomerID, &t.OrderID, &t.Points, &t.Reason, &t.CreatedBy, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
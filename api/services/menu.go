@@ -0,0 +1,47 @@
+package services
+
+import (
+	"math"
+
+	"wuwunchik.github.io/api/models"
+)
+
+// MenuAvailability рассчитывает число порций позиции меню, которые можно продать
+// из свободного остатка, и её доступность. Позиция без остатков автоматически
+// недоступна; ручное решение менеджера (AvailabilityOverride) имеет приоритет.
+func MenuAvailability(q Querier, item *models.MenuItem) error {
+	switch {
+	case item.DishID != nil:
+		d, err := LoadDish(q, *item.DishID)
+		if err != nil {
+			return err
+		}
+		capacity, err := DishCapacity(q, d)
+		if err != nil {
+			return err
+		}
+		item.AvailablePortions = capacity.Portions
+	case item.ProductID != nil:
+		available, err := AvailableQuantity(q, *item.ProductID)
+		if err != nil {
+			return err
+		}
+		var factor float64
+		err = q.QueryRow(`
+			SELECT u.factor FROM products p JOIN units u ON p.unit_id = u.id WHERE p.id = ?
+		`, *item.ProductID).Scan(&factor)
+		if err != nil {
+			return err
+		}
+		item.AvailablePortions = 0
+		if available > 0 && factor > 0 {
+			item.AvailablePortions = int(math.Floor(float64(available)/factor + 1e-9))
+		}
+	}
+
+	item.Available = item.Active && item.AvailablePortions > 0
+	if item.Active && item.AvailabilityOverride != nil {
+		item.Available = *item.AvailabilityOverride == models.AvailabilityAvailable
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+
+	"wuwunchik.github.io/api/models"
+)
+
+var (
+	ErrUnknownAllergen  = errors.New("unknown allergen")
+	ErrInvalidNutrition = errors.New("nutrition values must not be negative")
+)
+
+// ValidateAllergens проверяет аллергены по списку models.Allergens
+func ValidateAllergens(allergens []string) error {
+	for _, a := range allergens {
+		known := false
+		for _, k := range models.Allergens {
+			if a == k {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return ErrUnknownAllergen
+		}
+	}
+	return nil
+}
+
+// ValidateNutrition проверяет пищевую ценность продукта
+func ValidateNutrition(n *models.Nutrition) error {
+	if n == nil {
+		return nil
+	}
+	if n.EnergyKcal < 0 || n.Protein < 0 || n.Fat < 0 || n.Carbohydrates < 0 {
+		return ErrInvalidNutrition
+	}
+	return nil
+}
+
+// ProductAllergens возвращает аллергены продуктов в порядке models.Allergens
+func ProductAllergens(q Querier, productIDs []int) ([]string, error) {
+	present := make(map[string]bool)
+	for _, id := range productIDs {
+		rows, err := q.Query("SELECT allergen FROM product_allergens WHERE product_id = ?", id)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			var a string
+			if err := rows.Scan(&a); err != nil {
+				rows.Close()
+				return nil, err
+			}
+			present[a] = true
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	allergens := []string{}
+	for _, a := range models.Allergens {
+		if present[a] {
+			allergens = append(allergens, a)
+		}
+	}
+	return allergens, nil
+}
+
+// nutritionGrams переводит количество продукта в базовых единицах в граммы (миллилитры),
+// к которым относится пищевая ценность. Штучный продукт пересчитывается через пересчёт
+// единиц массы или объёма; без него ok = false.
+func nutritionGrams(q Querier, productID int, dimension string, baseQuantity float64) (float64, bool, error) {
+	if dimension != models.DimensionCount {
+		return baseQuantity, true, nil
+	}
+	var conversion, unitFactor float64
+	err := q.QueryRow(`
+		SELECT c.factor, u.factor FROM product_unit_conversions c
+		JOIN units u ON c.unit_id = u.id
+		WHERE c.product_id = ? AND u.dimension IN (?, ?) AND c.factor > 0
+		ORDER BY u.dimension, c.unit_id
+		LIMIT 1
+	`, productID, models.DimensionMass, models.DimensionVolume).Scan(&conversion, &unitFactor)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return baseQuantity / conversion * unitFactor, true, nil
+}
+
+// BuildDishLabel собирает этикетку блюда: аллергены всех ингредиентов и пищевую
+// ценность порции с учётом количества каждого ингредиента в рецептуре
+func BuildDishLabel(q Querier, d models.Dish) (models.DishLabel, error) {
+	label := models.DishLabel{
+		DishID:      d.ID,
+		DishName:    d.Name,
+		PortionSize: d.PortionSize,
+		Complete:    true,
+	}
+	if d.PortionUnitID != nil {
+		err := q.QueryRow("SELECT abbreviation FROM units WHERE id = ?", *d.PortionUnitID).Scan(&label.PortionUnit)
+		if err != nil && err != sql.ErrNoRows {
+			return label, err
+		}
+	}
+
+	productIDs := make([]int, 0, len(d.Ingredients))
+	for _, i := range d.Ingredients {
+		productIDs = append(productIDs, i.ProductID)
+
+		var dimension string
+		var energy, protein, fat, carbohydrates *float64
+		err := q.QueryRow(`
+			SELECT u.dimension, p.energy_kcal, p.protein, p.fat, p.carbohydrates
+			FROM products p JOIN units u ON p.unit_id = u.id
+			WHERE p.id = ?
+		`, i.ProductID).Scan(&dimension, &energy, &protein, &fat, &carbohydrates)
+		if err != nil {
+			return label, err
+		}
+		grams, ok, err := nutritionGrams(q, i.ProductID, dimension, i.PortionQuantity)
+		if err != nil {
+			return label, err
+		}
+		if energy == nil || !ok {
+			label.Complete = false
+			label.MissingNutrition = append(label.MissingNutrition, i.ProductName)
+			continue
+		}
+		label.Nutrition.EnergyKcal += *energy * grams / 100
+		label.Nutrition.Protein += *protein * grams / 100
+		label.Nutrition.Fat += *fat * grams / 100
+		label.Nutrition.Carbohydrates += *carbohydrates * grams / 100
+	}
+
+	label.Nutrition = models.Nutrition{
+		EnergyKcal:    math.Round(label.Nutrition.EnergyKcal),
+		Protein:       roundNutrient(label.Nutrition.Protein),
+		Fat:           roundNutrient(label.Nutrition.Fat),
+		Carbohydrates: roundNutrient(label.Nutrition.Carbohydrates),
+	}
+
+	allergens, err := ProductAllergens(q, productIDs)
+	if err != nil {
+		return label, err
+	}
+	label.Allergens = allergens
+	return label, nil
+}
+
+// roundNutrient округляет содержание нутриента до десятых грамма
+func roundNutrient(v float64) float64 {
+	return math.Round(v*10) / 10
+}
+
+// MenuAllergens заполняет аллергены позиции меню: блюда — по рецептуре, продукта — его собственные
+func MenuAllergens(q Querier, item *models.MenuItem) error {
+	var productIDs []int
+	switch {
+	case item.DishID != nil:
+		d, err := LoadDish(q, *item.DishID)
+		if err != nil {
+			return err
+		}
+		for _, i := range d.Ingredients {
+			productIDs = append(productIDs, i.ProductID)
+		}
+	case item.ProductID != nil:
+		productIDs = []int{*item.ProductID}
+	}
+
+	allergens, err := ProductAllergens(q, productIDs)
+	if err != nil {
+		return err
+	}
+	item.Allergens = allergens
+	return nil
+}
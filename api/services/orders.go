@@ -0,0 +1,265 @@
+package services
+
+import (
+	"database/sql"
+	"math"
+	"sort"
+
+	"wuwunchik.github.io/api/models"
+)
+
+// OrderReferenceType — тип документа в движениях и резервах, созданных заказом
+const OrderReferenceType = "order"
+
+// MissingIngredientsError — заказ или производство нельзя исполнить из-за нехватки продуктов
+type MissingIngredientsError struct {
+	Items []models.MissingIngredient
+}
+
+func (e *MissingIngredientsError) Error() string {
+	return "insufficient ingredients"
+}
+
+// OrderRequirements рассчитывает потребность заказа в продуктах (в базовых единицах):
+// для блюд — по рецептуре на порцию, для продуктов — в единице измерения продукта.
+// Дробная потребность округляется вверх.
+func OrderRequirements(q Querier, orderID int) (map[int]int, error) {
+	rows, err := q.Query(`
+		SELECT i.product_id, SUM(i.base_quantity * 1.0 / d.yield * oi.quantity)
+		FROM order_items oi
+		JOIN menu m ON oi.menu_item_id = m.id
+		JOIN dishes d ON m.dish_id = d.id
+		JOIN dish_ingredients i ON i.dish_id = d.id
+		WHERE oi.order_id = ?
+		GROUP BY i.product_id
+		UNION ALL
+		SELECT m.product_id, SUM(u.factor * oi.quantity)
+		FROM order_items oi
+		JOIN menu m ON oi.menu_item_id = m.id
+		JOIN products p ON m.product_id = p.id
+		JOIN units u ON p.unit_id = u.id
+		WHERE oi.order_id = ? AND m.dish_id IS NULL
+		GROUP BY m.product_id
+	`, orderID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	totals := make(map[int]float64)
+	for rows.Next() {
+		var productID int
+		var quantity float64
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		totals[productID] += quantity
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	requirements := make(map[int]int, len(totals))
+	for productID, quantity := range totals {
+		requirements[productID] = int(math.Ceil(quantity - 1e-9))
+	}
+	return requirements, nil
+}
+
+// ReserveOrderStock резервирует потребность открытого заказа в продуктах, заменяя его
+// прежние резервы. Резервируется только свободный остаток: нехватку заказа проверяет
+// DeductOrderStock при передаче на кухню.
+func ReserveOrderStock(tx *sql.Tx, orderID int, createdBy string) error {
+	if _, err := ReleaseReservations(tx, OrderReferenceType, orderID); err != nil {
+		return err
+	}
+	requirements, err := OrderRequirements(tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	for _, productID := range sortedProductIDs(requirements) {
+		available, err := AvailableQuantity(tx, productID)
+		if err != nil {
+			return err
+		}
+		quantity := min(requirements[productID], available)
+		if quantity <= 0 {
+			continue
+		}
+		err = ReserveStock(tx, &models.Reservation{
+			ProductID:     productID,
+			Quantity:      quantity,
+			ReferenceType: OrderReferenceType,
+			ReferenceID:   orderID,
+			CreatedBy:     createdBy,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sortedProductIDs возвращает продукты потребности по возрастанию id
+func sortedProductIDs(requirements map[int]int) []int {
+	productIDs := make([]int, 0, len(requirements))
+	for productID := range requirements {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Ints(productIDs)
+	return productIDs
+}
+
+// DeductOrderStock списывает продукты под заказ одной транзакцией, исполняя его резервы.
+// Потребность проверяется до списания, поэтому при нехватке ничего не списывается
+// и возвращается MissingIngredientsError со списком недостающих продуктов.
+func DeductOrderStock(tx *sql.Tx, orderID int, createdBy string) error {
+	requirements, err := OrderRequirements(tx, orderID)
+	if err != nil {
+		return err
+	}
+	productIDs := sortedProductIDs(requirements)
+
+	// Резервы самого заказа исполняются: его количество списывается ниже
+	if _, err := CompleteReservations(tx, OrderReferenceType, orderID); err != nil {
+		return err
+	}
+
+	if err := CheckRequirements(tx, requirements); err != nil {
+		return err
+	}
+
+	for _, productID := range productIDs {
+		if requirements[productID] == 0 {
+			continue
+		}
+		// Расход оценивается по скользящей средней, чтобы возврат при отмене
+		// вернул товар по той же стоимости
+		unitCost, err := MovingAverageCost(tx, productID)
+		if err != nil {
+			return err
+		}
+		_, err = IssueStock(tx, models.StockMovement{
+			ProductID:     productID,
+			MovementType:  models.MovementIssue,
+			Quantity:      requirements[productID],
+			UnitCost:      unitCost,
+			ReferenceType: OrderReferenceType,
+			ReferenceID:   orderID,
+			CreatedBy:     createdBy,
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreOrderStock возвращает на склад всё, что было списано под заказ,
+// в те же партии и места хранения
+func RestoreOrderStock(tx *sql.Tx, orderID int, createdBy string) error {
+	rows, err := tx.Query(`
+		SELECT product_id, -SUM(quantity), unit_cost, lot_id, location_id
+		FROM stock_movements
+		WHERE reference_type = ? AND reference_id = ? AND movement_type IN (?, ?)
+		GROUP BY product_id, unit_cost, lot_id, location_id
+		HAVING SUM(quantity) < 0
+		ORDER BY product_id, lot_id, location_id
+	`, OrderReferenceType, orderID, models.MovementIssue, models.MovementReturn)
+	if err != nil {
+		return err
+	}
+	var returns []models.StockMovement
+	for rows.Next() {
+		var m models.StockMovement
+		if err := rows.Scan(&m.ProductID, &m.Quantity, &m.UnitCost, &m.LotID, &m.LocationID); err != nil {
+			rows.Close()
+			return err
+		}
+		returns = append(returns, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, m := range returns {
+		m.MovementType = models.MovementReturn
+		m.ReferenceType = OrderReferenceType
+		m.ReferenceID = orderID
+		m.Note = "Отмена заказа"
+		m.CreatedBy = createdBy
+		if err := PostMovement(tx, &m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckRequirements проверяет, что свободного остатка хватает на потребность
+// (в базовых единицах по продуктам); иначе возвращает MissingIngredientsError.
+// Свободный остаток (AvailableQuantity) — тот же, из которого IssueStock списывает
+// расход без места хранения: все места хранения без заблокированных и просроченных партий.
+func CheckRequirements(q Querier, requirements map[int]int) error {
+	return checkRequirements(q, requirements, func(productID int) (int, error) {
+		return AvailableQuantity(q, productID)
+	})
+}
+
+// CheckLocationRequirements проверяет потребность по остатку одного места хранения:
+// доступно не больше свободного остатка продукта и не больше, чем лежит в этом месте.
+func CheckLocationRequirements(q Querier, locationID int, requirements map[int]int) error {
+	var exists int
+	if err := q.QueryRow("SELECT COUNT(*) FROM locations WHERE id = ?", locationID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrLocationNotFound
+	}
+	return checkRequirements(q, requirements, func(productID int) (int, error) {
+		available, err := AvailableQuantity(q, productID)
+		if err != nil {
+			return 0, err
+		}
+		var stored int
+		err = q.QueryRow(`
+			SELECT COALESCE(SUM(quantity), 0) FROM location_stock WHERE location_id = ? AND product_id = ?
+		`, locationID, productID).Scan(&stored)
+		return min(available, stored), err
+	})
+}
+
+// checkRequirements сравнивает потребность с доступным количеством, которое возвращает available
+func checkRequirements(q Querier, requirements map[int]int, available func(productID int) (int, error)) error {
+	var missing []models.MissingIngredient
+	for _, productID := range sortedProductIDs(requirements) {
+		available, err := available(productID)
+		if err != nil {
+			return err
+		}
+		required := requirements[productID]
+		if available >= required {
+			continue
+		}
+		item := models.MissingIngredient{
+			ProductID: productID,
+			Required:  required,
+			Available: available,
+			Missing:   required - available,
+		}
+		var dimension string
+		err = q.QueryRow(`
+			SELECT p.name, u.dimension FROM products p JOIN units u ON p.unit_id = u.id WHERE p.id = ?
+		`, productID).Scan(&item.ProductName, &dimension)
+		if err != nil {
+			return err
+		}
+		item.BaseUnit = models.BaseUnits[dimension]
+		missing = append(missing, item)
+	}
+	if len(missing) > 0 {
+		return &MissingIngredientsError{Items: missing}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"wuwunchik.github.io/api/models"
+)
+
+var ErrPackageNotFound = errors.New("package not found")
+
+// PackageToBase переводит количество упаковок продукта в базовые единицы
+func PackageToBase(q Querier, productID, packageID int, count float64) (int, error) {
+	if count <= 0 {
+		return 0, ErrInvalidQuantity
+	}
+	var quantity int
+	err := q.QueryRow("SELECT quantity FROM product_packages WHERE id = ? AND product_id = ?", packageID, productID).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		return 0, ErrPackageNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	base := int(math.Round(count * float64(quantity)))
+	if base <= 0 {
+		return 0, ErrInvalidQuantity
+	}
+	return base, nil
+}
+
+// BreakdownStock раскладывает количество (в базовых единицах) по упаковкам, начиная
+// с самой крупной; остаток вне упаковок выражается в единице продукта unit
+func BreakdownStock(quantity int, packages []models.ProductPackage, unit models.Unit) models.StockBreakdown {
+	sorted := append([]models.ProductPackage(nil), packages...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Quantity > sorted[j].Quantity })
+
+	breakdown := models.StockBreakdown{Packages: []models.PackageCount{}}
+	var parts []string
+	remaining := quantity
+	for _, p := range sorted {
+		if p.Quantity <= 0 || remaining < p.Quantity {
+			continue
+		}
+		count := remaining / p.Quantity
+		remaining -= count * p.Quantity
+		breakdown.Packages = append(breakdown.Packages, models.PackageCount{PackageID: p.ID, Name: p.Name, Count: count})
+		parts = append(parts, strconv.Itoa(count)+" × "+p.Name)
+	}
+	breakdown.Remainder = remaining
+
+	if remaining != 0 || len(parts) == 0 {
+		factor := unit.Factor
+		if factor <= 0 {
+			factor = 1
+		}
+		parts = append(parts, strconv.FormatFloat(float64(remaining)/factor, 'f', -1, 64)+" "+unit.Abbreviation)
+	}
+	breakdown.Text = strings.Join(parts, " + ")
+	return breakdown
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+var ErrInvalidPrice = errors.New("prices must not be negative and currency must be a 3-letter code")
+
+// ValidatePrice проверяет цены и код валюты (три заглавные латинские буквы)
+func ValidatePrice(costPrice, salePrice float64, currency string) error {
+	if costPrice < 0 || salePrice < 0 || len(currency) != 3 {
+		return ErrInvalidPrice
+	}
+	for _, c := range currency {
+		if c < 'A' || c > 'Z' {
+			return ErrInvalidPrice
+		}
+	}
+	return nil
+}
+
+// ResolvePrice возвращает цены и валюту продукта после изменения: не переданные (nil)
+// значения берутся из текущих цен продукта
+func ResolvePrice(q Querier, productID int, costPrice, salePrice *float64, currency *string) (float64, float64, string, error) {
+	var cost, sale float64
+	var cur string
+	err := q.QueryRow("SELECT cost_price, sale_price, currency FROM products WHERE id = ?", productID).
+		Scan(&cost, &sale, &cur)
+	if err == sql.ErrNoRows {
+		return 0, 0, "", ErrProductNotFound
+	}
+	if err != nil {
+		return 0, 0, "", err
+	}
+	if costPrice != nil {
+		cost = *costPrice
+	}
+	if salePrice != nil {
+		sale = *salePrice
+	}
+	if currency != nil {
+		cur = *currency
+	}
+	return cost, sale, cur, nil
+}
+
+// ApplyPrice устанавливает цены продукта и добавляет запись в историю цен.
+// Если цены не изменились, история не пополняется.
+func ApplyPrice(tx *sql.Tx, productID int, costPrice, salePrice float64, currency, changedBy string, effectiveFrom time.Time) error {
+	if err := ValidatePrice(costPrice, salePrice, currency); err != nil {
+		return err
+	}
+
+	var oldCost, oldSale float64
+	var oldCurrency string
+	err := tx.QueryRow("SELECT cost_price, sale_price, currency FROM products WHERE id = ?", productID).
+		Scan(&oldCost, &oldSale, &oldCurrency)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if oldCost == costPrice && oldSale == salePrice && oldCurrency == currency {
+		return nil
+	}
+
+	_, err = tx.Exec("UPDATE products SET cost_price = ?, sale_price = ?, currency = ? WHERE id = ?",
+		costPrice, salePrice, currency, productID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO price_history (product_id, old_cost_price, old_sale_price, cost_price, sale_price, currency, effective_from, changed_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, productID, oldCost, oldSale, costPrice, salePrice, currency, utils.FormatDBTime(effectiveFrom), changedBy)
+	return err
+}
+
+// ApplyScheduledPrices применяет запланированные изменения цен, срок которых наступил
+func ApplyScheduledPrices() (int, error) {
+	tx, err := database.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, product_id, cost_price, sale_price, currency, effective_from, created_by
+		FROM scheduled_prices
+		WHERE applied_at IS NULL AND cancelled_at IS NULL AND effective_from <= ?
+		ORDER BY effective_from, id
+	`, utils.FormatDBTime(time.Now()))
+	if err != nil {
+		return 0, err
+	}
+
+	var due []models.ScheduledPrice
+	for rows.Next() {
+		var s models.ScheduledPrice
+		err := rows.Scan(&s.ID, &s.ProductID, &s.CostPrice, &s.SalePrice, &s.Currency, &s.EffectiveFrom, &s.CreatedBy)
+		if err != nil {
+			rows.Close()
+			return 0, err
+		}
+		due = append(due, s)
+	}
+	rows.Close()
+
+	// Применяются только поля, переданные в изменении; остальные цены остаются текущими
+	for _, s := range due {
+		costPrice, salePrice, currency, err := ResolvePrice(tx, s.ProductID, s.CostPrice, s.SalePrice, s.Currency)
+		if err == nil {
+			effectiveFrom, _ := utils.ParseDateTime(s.EffectiveFrom)
+			err = ApplyPrice(tx, s.ProductID, costPrice, salePrice, currency, s.CreatedBy, effectiveFrom)
+		}
+		if err != nil && err != ErrProductNotFound {
+			return 0, err
+		}
+		if _, err := tx.Exec("UPDATE scheduled_prices SET applied_at = CURRENT_TIMESTAMP WHERE id = ?", s.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(due), tx.Commit()
+}
+
+// StartPriceScheduler запускает фоновое применение запланированных цен с заданным интервалом
+func StartPriceScheduler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if applied, err := ApplyScheduledPrices(); err != nil {
+				log.Println("Scheduled price update failed:", err)
+			} else if applied > 0 {
+				log.Printf("Applied %d scheduled price changes", applied)
+			}
+			<-ticker.C
+		}
+	}()
+}
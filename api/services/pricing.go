@@ -0,0 +1,265 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+var (
+	ErrPriceListNotFound = errors.New("price list not found")
+	ErrPriceListInactive = errors.New("price list is inactive")
+	ErrPromotionNotFound = errors.New("promotion not found")
+	ErrCurrencyMismatch  = errors.New("product sale price is in a different currency than the price list")
+)
+
+// LoadPriceList загружает прайс-лист; с items — вместе с ценами продуктов
+func LoadPriceList(q Querier, id int, items bool) (models.PriceList, error) {
+	var pl models.PriceList
+	err := q.QueryRow(`
+		SELECT id, name, description, currency, active FROM price_lists WHERE id = ?
+	`, id).Scan(&pl.ID, &pl.Name, &pl.Description, &pl.Currency, &pl.Active)
+	if err == sql.ErrNoRows {
+		return pl, ErrPriceListNotFound
+	}
+	if err != nil || !items {
+		return pl, err
+	}
+
+	rows, err := q.Query(`
+		SELECT i.product_id, p.name, i.price
+		FROM price_list_items i
+		JOIN products p ON i.product_id = p.id
+		WHERE i.price_list_id = ?
+		ORDER BY p.name
+	`, id)
+	if err != nil {
+		return pl, err
+	}
+	defer rows.Close()
+	pl.Items = []models.PriceListItem{}
+	for rows.Next() {
+		var item models.PriceListItem
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.Price); err != nil {
+			return pl, err
+		}
+		pl.Items = append(pl.Items, item)
+	}
+	return pl, rows.Err()
+}
+
+// promotionSelect — общий запрос акций
+const promotionSelect = `
+	SELECT id, name, promo_type, value, buy_quantity, free_quantity, product_id, price_list_id,
+		starts_at, ends_at, active
+	FROM promotions
+`
+
+// scanPromotion считывает акцию, выбранную запросом promotionSelect
+func scanPromotion(row interface{ Scan(...interface{}) error }) (models.Promotion, error) {
+	var p models.Promotion
+	err := row.Scan(&p.ID, &p.Name, &p.PromoType, &p.Value, &p.BuyQuantity, &p.FreeQuantity, &p.ProductID, &p.PriceListID,
+		&p.StartsAt, &p.EndsAt, &p.Active)
+	return p, err
+}
+
+// LoadPromotion загружает акцию
+func LoadPromotion(q Querier, id int) (models.Promotion, error) {
+	p, err := scanPromotion(q.QueryRow(promotionSelect+" WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return p, ErrPromotionNotFound
+	}
+	return p, err
+}
+
+// FindPromotions возвращает акции, отобранные условием where (пустое — все акции)
+func FindPromotions(q Querier, where string, args ...interface{}) ([]models.Promotion, error) {
+	query := promotionSelect
+	if where != "" {
+		query += " WHERE " + where
+	}
+	rows, err := q.Query(query+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	promotions := []models.Promotion{}
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, rows.Err()
+}
+
+// ActivePromotions возвращает акции, действующие в момент at для прайс-листа
+// (акции без прайс-листа действуют во всех каналах)
+func ActivePromotions(q Querier, priceListID *int, at time.Time) ([]models.Promotion, error) {
+	return FindPromotions(q, `
+		active = 1 AND starts_at <= ? AND (ends_at IS NULL OR ends_at >= ?)
+			AND (price_list_id IS NULL OR price_list_id = ?)
+	`, utils.FormatDBTime(at), utils.FormatDBTime(at), priceListID)
+}
+
+// formatMoney форматирует сумму для пояснения расчёта
+func formatMoney(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// formatQuantity форматирует количество без лишних нулей
+func formatQuantity(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// linePromotion рассчитывает скидку акции на строку корзины; ok = false, если акция
+// к строке не применима
+func linePromotion(p models.Promotion, line models.BasketLine) (models.AppliedPromotion, bool) {
+	applied := models.AppliedPromotion{PromotionID: p.ID, Name: p.Name, PromoType: p.PromoType}
+	switch p.PromoType {
+	case models.PromotionPercentage:
+		applied.Discount = utils.RoundMoney(line.Subtotal * p.Value / 100)
+		applied.Explanation = fmt.Sprintf("скидка %s%% с %s = %s",
+			formatQuantity(p.Value), formatMoney(line.Subtotal), formatMoney(applied.Discount))
+	case models.PromotionFixed:
+		if p.ProductID == nil {
+			return applied, false
+		}
+		perUnit := math.Min(p.Value, line.UnitPrice)
+		applied.Discount = utils.RoundMoney(perUnit * line.Quantity)
+		applied.Explanation = fmt.Sprintf("скидка %s за %s: %s × %s = %s",
+			formatMoney(perUnit), line.Unit, formatQuantity(line.Quantity), formatMoney(perUnit), formatMoney(applied.Discount))
+	case models.PromotionBuyXGetY:
+		group := p.BuyQuantity + p.FreeQuantity
+		if group <= 0 {
+			return applied, false
+		}
+		free := math.Floor(line.Quantity/float64(group)+1e-9) * float64(p.FreeQuantity)
+		if free == 0 {
+			return applied, false
+		}
+		applied.Discount = utils.RoundMoney(free * line.UnitPrice)
+		applied.Explanation = fmt.Sprintf("%d+%d: из %s %s бесплатно %s × %s = %s",
+			p.BuyQuantity, p.FreeQuantity, formatQuantity(line.Quantity), line.Unit,
+			formatQuantity(free), formatMoney(line.UnitPrice), formatMoney(applied.Discount))
+	default:
+		return applied, false
+	}
+	return applied, applied.Discount > 0
+}
+
+// PriceBasket рассчитывает корзину на момент at. Цена строки берётся из прайс-листа,
+// а если продукта в нём нет — из цены продажи продукта. К каждой строке применяется
+// одна, самая выгодная акция; фиксированные скидки без продукта применяются к корзине
+// после скидок на строки и не превышают оставшуюся сумму.
+func PriceBasket(q Querier, priceListID *int, items []models.BasketItem, at time.Time) (models.BasketPrice, error) {
+	basket := models.BasketPrice{
+		PriceListID: priceListID,
+		Currency:    models.DefaultCurrency,
+		PricedAt:    at.UTC().Format(time.RFC3339),
+		Lines:       []models.BasketLine{},
+		Applied:     []models.AppliedPromotion{},
+	}
+	if priceListID != nil {
+		pl, err := LoadPriceList(q, *priceListID, false)
+		if err != nil {
+			return basket, err
+		}
+		if !pl.Active {
+			return basket, ErrPriceListInactive
+		}
+		basket.PriceListName = pl.Name
+		basket.Currency = pl.Currency
+	}
+
+	promotions, err := ActivePromotions(q, priceListID, at)
+	if err != nil {
+		return basket, err
+	}
+
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return basket, ErrInvalidQuantity
+		}
+		line := models.BasketLine{ProductID: item.ProductID, Quantity: item.Quantity, Applied: []models.AppliedPromotion{}}
+		var salePrice float64
+		var currency string
+		var listPrice *float64
+		err := q.QueryRow(`
+			SELECT p.name, u.abbreviation, p.sale_price, p.currency,
+				(SELECT price FROM price_list_items WHERE price_list_id = ? AND product_id = p.id)
+			FROM products p JOIN units u ON p.unit_id = u.id
+			WHERE p.id = ?
+		`, priceListID, item.ProductID).Scan(&line.ProductName, &line.Unit, &salePrice, &currency, &listPrice)
+		if err == sql.ErrNoRows {
+			return basket, ErrProductNotFound
+		}
+		if err != nil {
+			return basket, err
+		}
+		if listPrice != nil {
+			line.UnitPrice = *listPrice
+			line.PriceSource = models.PriceSourcePriceList
+		} else {
+			if currency != basket.Currency {
+				return basket, fmt.Errorf("%w: %s", ErrCurrencyMismatch, line.ProductName)
+			}
+			line.UnitPrice = salePrice
+			line.PriceSource = models.PriceSourceSalePrice
+		}
+		line.Subtotal = utils.RoundMoney(line.UnitPrice * line.Quantity)
+
+		var best *models.AppliedPromotion
+		for _, p := range promotions {
+			if p.ProductID != nil && *p.ProductID != line.ProductID {
+				continue
+			}
+			applied, ok := linePromotion(p, line)
+			if ok && (best == nil || applied.Discount > best.Discount) {
+				best = &applied
+			}
+		}
+		if best != nil {
+			best.Discount = math.Min(best.Discount, line.Subtotal)
+			line.Discount = best.Discount
+			line.Applied = append(line.Applied, *best)
+		}
+		line.Total = utils.RoundMoney(line.Subtotal - line.Discount)
+
+		basket.Subtotal += line.Subtotal
+		basket.Discount += line.Discount
+		basket.Lines = append(basket.Lines, line)
+	}
+
+	for _, p := range promotions {
+		if p.PromoType != models.PromotionFixed || p.ProductID != nil {
+			continue
+		}
+		remaining := utils.RoundMoney(basket.Subtotal - basket.Discount)
+		discount := utils.RoundMoney(math.Min(p.Value, remaining))
+		if discount <= 0 {
+			continue
+		}
+		basket.Discount += discount
+		basket.Applied = append(basket.Applied, models.AppliedPromotion{
+			PromotionID: p.ID,
+			Name:        p.Name,
+			PromoType:   p.PromoType,
+			Discount:    discount,
+			Explanation: fmt.Sprintf("скидка %s с корзины на %s", formatMoney(discount), formatMoney(remaining)),
+		})
+	}
+
+	basket.Subtotal = utils.RoundMoney(basket.Subtotal)
+	basket.Discount = utils.RoundMoney(basket.Discount)
+	basket.Total = utils.RoundMoney(basket.Subtotal - basket.Discount)
+	return basket, nil
+}
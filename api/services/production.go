@@ -0,0 +1,197 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"sort"
+
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// ProductionReferenceType — тип документа в движениях, созданных выпуском
+const ProductionReferenceType = "production"
+
+var ErrUnknownRecipeYield = errors.New("recipe has no portion size, expected yield is unknown")
+
+// RunProduction проводит выпуск одной транзакцией: списывает ингредиенты рецептуры
+// на run.Batches выходов и приходует run.ActualQuantity (в базовых единицах) продукта
+// по себестоимости списанного. Ожидаемый выход — выход рецепта (порции × размер порции),
+// пересчитанный в базовые единицы продукта. Ингредиенты списываются из run.SourceLocationID,
+// а без него — по общему правилу расхода (PostIssue). Если ингредиентов не хватает,
+// возвращается MissingIngredientsError.
+func RunProduction(tx *sql.Tx, run *models.ProductionRun) error {
+	if run.Batches <= 0 || run.ActualQuantity < 0 {
+		return ErrInvalidQuantity
+	}
+	d, err := LoadDish(tx, run.DishID)
+	if err != nil {
+		return err
+	}
+	if d.PortionSize <= 0 || d.PortionUnitID == nil {
+		return ErrUnknownRecipeYield
+	}
+	expected, err := ConvertToBase(tx, run.ProductID, *d.PortionUnitID, float64(d.Yield)*d.PortionSize*run.Batches)
+	if err != nil {
+		return err
+	}
+	run.ExpectedQuantity = int(math.Round(expected))
+
+	requirements := make(map[int]int)
+	for _, i := range d.Ingredients {
+		requirements[i.ProductID] += int(math.Ceil(i.BaseQuantity*run.Batches - 1e-9))
+	}
+	if run.SourceLocationID != nil {
+		err = CheckLocationRequirements(tx, *run.SourceLocationID, requirements)
+	} else {
+		err = CheckRequirements(tx, requirements)
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO production_runs (dish_id, product_id, batches, expected_quantity, actual_quantity, location_id, source_location_id, lot_id, note, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.DishID, run.ProductID, run.Batches, run.ExpectedQuantity, run.ActualQuantity,
+		run.LocationID, run.SourceLocationID, run.LotID, run.Note, run.CreatedBy)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	run.ID = int(id)
+
+	productIDs := make([]int, 0, len(requirements))
+	for productID := range requirements {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Ints(productIDs)
+
+	var cost float64
+	for _, productID := range productIDs {
+		quantity := requirements[productID]
+		if quantity == 0 {
+			continue
+		}
+		var unitCost float64
+		err := tx.QueryRow(`
+			SELECT CASE WHEN u.factor > 0 THEN p.cost_price / u.factor ELSE 0 END
+			FROM products p JOIN units u ON p.unit_id = u.id WHERE p.id = ?
+		`, productID).Scan(&unitCost)
+		if err != nil {
+			return err
+		}
+
+		issue := models.StockMovement{
+			ProductID:     productID,
+			MovementType:  models.MovementIssue,
+			Quantity:      quantity,
+			UnitCost:      unitCost,
+			ReferenceType: ProductionReferenceType,
+			ReferenceID:   run.ID,
+			Note:          "Выпуск: " + d.Name,
+			CreatedBy:     run.CreatedBy,
+		}
+		if run.SourceLocationID != nil {
+			issue.LocationID = *run.SourceLocationID
+		}
+		if _, err := IssueStock(tx, issue, nil); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO production_materials (run_id, product_id, quantity, unit_cost) VALUES (?, ?, ?, ?)
+		`, run.ID, productID, quantity, unitCost)
+		if err != nil {
+			return err
+		}
+		cost += float64(quantity) * unitCost
+	}
+
+	cost = utils.RoundMoney(cost)
+	if _, err := tx.Exec("UPDATE production_runs SET ingredient_cost = ? WHERE id = ?", cost, run.ID); err != nil {
+		return err
+	}
+
+	if run.ActualQuantity == 0 {
+		// Выход полностью испорчен: ингредиенты списаны, приходовать нечего
+		return nil
+	}
+	receipt := models.StockMovement{
+		ProductID:     run.ProductID,
+		MovementType:  models.MovementProduction,
+		Quantity:      run.ActualQuantity,
+		UnitCost:      cost / float64(run.ActualQuantity),
+		ReferenceType: ProductionReferenceType,
+		ReferenceID:   run.ID,
+		Note:          run.Note,
+		CreatedBy:     run.CreatedBy,
+	}
+	if run.LocationID != nil {
+		receipt.LocationID = *run.LocationID
+	}
+	if run.LotID != nil {
+		receipt.LotID = *run.LotID
+	}
+	return PostMovement(tx, &receipt)
+}
+
+// LoadProductionRun загружает выпуск со списанными ингредиентами
+func LoadProductionRun(q Querier, id int) (models.ProductionRun, error) {
+	var run models.ProductionRun
+	var dimension string
+	err := q.QueryRow(`
+		SELECT r.id, r.dish_id, d.name, r.product_id, p.name, u.dimension, r.batches,
+			r.expected_quantity, r.actual_quantity, r.ingredient_cost, r.location_id, r.source_location_id, r.lot_id,
+			r.note, r.created_by, r.created_at
+		FROM production_runs r
+		JOIN dishes d ON r.dish_id = d.id
+		JOIN products p ON r.product_id = p.id
+		JOIN units u ON p.unit_id = u.id
+		WHERE r.id = ?
+	`, id).Scan(&run.ID, &run.DishID, &run.DishName, &run.ProductID, &run.ProductName, &dimension, &run.Batches,
+		&run.ExpectedQuantity, &run.ActualQuantity, &run.IngredientCost, &run.LocationID, &run.SourceLocationID, &run.LotID,
+		&run.Note, &run.CreatedBy, &run.CreatedAt)
+	if err != nil {
+		return run, err
+	}
+	fillProductionVariance(&run, dimension)
+
+	rows, err := q.Query(`
+		SELECT m.product_id, p.name, m.quantity, m.unit_cost
+		FROM production_materials m
+		JOIN products p ON m.product_id = p.id
+		WHERE m.run_id = ?
+		ORDER BY p.name, m.product_id
+	`, id)
+	if err != nil {
+		return run, err
+	}
+	defer rows.Close()
+
+	run.Ingredients = []models.ProductionMaterial{}
+	for rows.Next() {
+		var m models.ProductionMaterial
+		if err := rows.Scan(&m.ProductID, &m.ProductName, &m.Quantity, &m.UnitCost); err != nil {
+			return run, err
+		}
+		m.Cost = utils.RoundMoney(float64(m.Quantity) * m.UnitCost)
+		run.Ingredients = append(run.Ingredients, m)
+	}
+	return run, rows.Err()
+}
+
+// fillProductionVariance рассчитывает отклонение выхода и себестоимость единицы выхода
+func fillProductionVariance(run *models.ProductionRun, dimension string) {
+	run.BaseUnit = models.BaseUnits[dimension]
+	run.Variance = run.ActualQuantity - run.ExpectedQuantity
+	run.VariancePercent = 0
+	if run.ExpectedQuantity > 0 {
+		run.VariancePercent = math.Round(float64(run.Variance)*10000/float64(run.ExpectedQuantity)) / 100
+	}
+	run.UnitCost = 0
+	if run.ActualQuantity > 0 {
+		run.UnitCost = run.IngredientCost / float64(run.ActualQuantity)
+	}
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"wuwunchik.github.io/api/database"
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// DefaultReservationTTL — срок резерва, если он не указан явно
+const DefaultReservationTTL = 24 * time.Hour
+
+var (
+	ErrInsufficientAvailableStock = errors.New("insufficient available stock: the rest is reserved or in blocked lots")
+	ErrReservationNotFound        = errors.New("reservation not found")
+	ErrReservationNotActive       = errors.New("reservation is not active")
+)
+
+// activeReservation — условие действующего резерва (ещё не истёкшего)
+const activeReservation = `status = 'active' AND (expires_at IS NULL OR expires_at > CURRENT_TIMESTAMP)`
+
+// ReservedQuantities возвращает зарезервированное количество по продуктам
+func ReservedQuantities(q Querier) (map[int]int, error) {
+	rows, err := q.Query("SELECT product_id, SUM(quantity) FROM reservations WHERE " + activeReservation + " GROUP BY product_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reserved := make(map[int]int)
+	for rows.Next() {
+		var productID, quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		reserved[productID] = quantity
+	}
+	return reserved, rows.Err()
+}
+
+// AvailableQuantity возвращает остаток продукта, который можно израсходовать: за вычетом
+// действующих резервов и партий, заблокированных или просроченных (их не расходует IssueStock)
+func AvailableQuantity(q Querier, productID int) (int, error) {
+	var available int
+	err := q.QueryRow(`
+		SELECT p.quantity
+			- COALESCE((SELECT SUM(quantity) FROM reservations WHERE product_id = p.id AND `+activeReservation+`), 0)
+			- COALESCE((SELECT SUM(quantity) FROM lots WHERE product_id = p.id AND quantity > 0 AND `+unusableLot+`), 0)
+		FROM products p WHERE p.id = ?
+	`, time.Now().Format("2006-01-02"), productID).Scan(&available)
+	if err == sql.ErrNoRows {
+		return 0, ErrProductNotFound
+	}
+	return available, err
+}
+
+// ReserveStock резервирует товар под документ. Резервировать можно только
+// свободный остаток; без срока резерв действует DefaultReservationTTL.
+func ReserveStock(tx *sql.Tx, r *models.Reservation) error {
+	if r.Quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+	available, err := AvailableQuantity(tx, r.ProductID)
+	if err != nil {
+		return err
+	}
+	if available < r.Quantity {
+		return ErrInsufficientAvailableStock
+	}
+
+	if r.ExpiresAt == nil {
+		expiresAt := utils.FormatDBTime(time.Now().Add(DefaultReservationTTL))
+		r.ExpiresAt = &expiresAt
+	}
+	r.Status = models.ReservationActive
+
+	result, err := tx.Exec(`
+		INSERT INTO reservations (product_id, quantity, location_id, reference_type, reference_id, status, expires_at, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.ProductID, r.Quantity, r.LocationID, r.ReferenceType, r.ReferenceID, r.Status, r.ExpiresAt, r.CreatedBy)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	r.ID = int(id)
+	return tx.QueryRow("SELECT expires_at, created_at FROM reservations WHERE id = ?", r.ID).Scan(&r.ExpiresAt, &r.CreatedAt)
+}
+
+// ReleaseReservations снимает все действующие резервы документа (например, при его отмене)
+func ReleaseReservations(tx *sql.Tx, referenceType string, referenceID int) (int64, error) {
+	return closeReservations(tx, referenceType, referenceID, models.ReservationReleased)
+}
+
+// CompleteReservations отмечает действующие резервы документа исполненными, когда товар
+// списывает сам документ (например, заказ при передаче на кухню)
+func CompleteReservations(tx *sql.Tx, referenceType string, referenceID int) (int64, error) {
+	return closeReservations(tx, referenceType, referenceID, models.ReservationFulfilled)
+}
+
+func closeReservations(tx *sql.Tx, referenceType string, referenceID int, status string) (int64, error) {
+	result, err := tx.Exec(`
+		UPDATE reservations SET status = ?, closed_at = CURRENT_TIMESTAMP
+		WHERE reference_type = ? AND reference_id = ? AND status = ?
+	`, status, referenceType, referenceID, models.ReservationActive)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// FulfilReservation списывает зарезервированный товар движением расхода (FEFO)
+// и закрывает резерв. Истёкший резерв исполнить нельзя.
+func FulfilReservation(tx *sql.Tx, id int, createdBy string) ([]models.StockMovement, error) {
+	var r models.Reservation
+	var active bool
+	err := tx.QueryRow(`
+		SELECT product_id, quantity, location_id, reference_type, reference_id, `+activeReservation+`
+		FROM reservations WHERE id = ?
+	`, id).Scan(&r.ProductID, &r.Quantity, &r.LocationID, &r.ReferenceType, &r.ReferenceID, &active)
+	if err == sql.ErrNoRows {
+		return nil, ErrReservationNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrReservationNotActive
+	}
+
+	// Резерв закрывается до списания, чтобы его количество стало доступным для расхода
+	_, err = tx.Exec("UPDATE reservations SET status = ?, closed_at = CURRENT_TIMESTAMP WHERE id = ?",
+		models.ReservationFulfilled, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return IssueStock(tx, models.StockMovement{
+		ProductID:     r.ProductID,
+		MovementType:  models.MovementIssue,
+		Quantity:      r.Quantity,
+		LocationID:    r.LocationID,
+		ReferenceType: r.ReferenceType,
+		ReferenceID:   r.ReferenceID,
+		Note:          "Резерв",
+		CreatedBy:     createdBy,
+	}, nil)
+}
+
+// ExpireReservations помечает истёкшие резервы
+func ExpireReservations() (int64, error) {
+	result, err := database.DB.Exec(`
+		UPDATE reservations SET status = ?, closed_at = CURRENT_TIMESTAMP
+		WHERE status = ? AND expires_at IS NOT NULL AND expires_at <= CURRENT_TIMESTAMP
+	`, models.ReservationExpired, models.ReservationActive)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// StartReservationExpiryChecker запускает периодическое снятие истёкших резервов
+func StartReservationExpiryChecker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if expired, err := ExpireReservations(); err != nil {
+				log.Println("Reservation expiry check failed:", err)
+			} else if expired > 0 {
+				log.Printf("Released %d expired reservations", expired)
+			}
+			<-ticker.C
+		}
+	}()
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// Параметры списка закупки по умолчанию
+const (
+	DefaultLookbackDays = 30 // период, по которому считается средний расход
+	DefaultCoverageDays = 7  // на сколько дней после поставки должно хватить запаса
+)
+
+// AverageDailyUsage возвращает средний дневной расход продуктов (в базовых единицах)
+// за lookbackDays дней до now — по тому же потреблению, что и прогноз (dailyUsage)
+func AverageDailyUsage(q Querier, lookbackDays int, now time.Time) (map[int]float64, error) {
+	daily, err := dailyUsage(q, lookbackDays, now)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[int]float64)
+	for productID, series := range daily {
+		var total float64
+		for _, quantity := range series {
+			total += quantity
+		}
+		if total > 0 {
+			usage[productID] = total / float64(lookbackDays)
+		}
+	}
+	return usage, nil
+}
+
+// OnOrderQuantities возвращает ещё не принятые количества по незакрытым заказам
+// поставщикам (включая черновики) в базовых единицах
+func OnOrderQuantities(q Querier) (map[int]int, error) {
+	rows, err := q.Query(`
+		SELECT l.product_id, SUM(MAX(l.base_quantity - l.received_quantity, 0))
+		FROM purchase_order_lines l
+		JOIN purchase_orders po ON l.order_id = po.id
+		WHERE po.status IN (?, ?, ?)
+		GROUP BY l.product_id
+	`, models.PurchaseDraft, models.PurchaseSent, models.PurchasePartiallyReceived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	onOrder := make(map[int]int)
+	for rows.Next() {
+		var productID, quantity int
+		if err := rows.Scan(&productID, &quantity); err != nil {
+			return nil, err
+		}
+		onOrder[productID] = quantity
+	}
+	return onOrder, rows.Err()
+}
+
+// preferredSupplier — условия поставки продукта у выбранного поставщика
+type preferredSupplier struct {
+	supplierID   int
+	name         string
+	leadTimeDays int
+	unitID       int
+	unit         string
+	factor       float64
+	price        float64
+}
+
+// preferredSuppliers выбирает для каждого продукта поставщика с наименьшей ценой
+// за базовую единицу (поставщики без цены — в последнюю очередь, затем по сроку поставки)
+func preferredSuppliers(q Querier) (map[int]preferredSupplier, error) {
+	rows, err := q.Query(`
+		SELECT sp.product_id, sp.supplier_id, s.name, s.lead_time_days, sp.unit_id, u.abbreviation, u.factor, sp.last_price
+		FROM supplier_products sp
+		JOIN suppliers s ON sp.supplier_id = s.id
+		JOIN units u ON sp.unit_id = u.id
+		ORDER BY sp.product_id, sp.last_price = 0,
+			CASE WHEN u.factor > 0 THEN sp.last_price / u.factor ELSE sp.last_price END,
+			s.lead_time_days, s.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppliers := make(map[int]preferredSupplier)
+	for rows.Next() {
+		var productID int
+		var s preferredSupplier
+		if err := rows.Scan(&productID, &s.supplierID, &s.name, &s.leadTimeDays, &s.unitID, &s.unit, &s.factor, &s.price); err != nil {
+			return nil, err
+		}
+		if _, ok := suppliers[productID]; !ok {
+			suppliers[productID] = s
+		}
+	}
+	return suppliers, rows.Err()
+}
+
+// BuildShoppingList формирует список закупки. Для каждого продукта требуемый уровень —
+// наибольшее из целевого остатка, точки заказа и минимального остатка плюс расход
+// за срок поставки и coverageDays дней (по среднему расходу за lookbackDays).
+// К закупке предлагается разница между требуемым уровнем и свободным остатком
+// вместе с уже заказанным количеством.
+func BuildShoppingList(q Querier, lookbackDays, coverageDays int, now time.Time) (models.ShoppingList, error) {
+	list := models.ShoppingList{
+		GeneratedAt:  now.UTC().Format(time.RFC3339),
+		LookbackDays: lookbackDays,
+		CoverageDays: coverageDays,
+		Currency:     models.DefaultCurrency,
+		Groups:       []models.ShoppingGroup{},
+	}
+
+	usage, err := AverageDailyUsage(q, lookbackDays, now)
+	if err != nil {
+		return list, err
+	}
+	onOrder, err := OnOrderQuantities(q)
+	if err != nil {
+		return list, err
+	}
+	reserved, err := ReservedQuantities(q)
+	if err != nil {
+		return list, err
+	}
+	blocked, err := BlockedQuantities(q)
+	if err != nil {
+		return list, err
+	}
+	suppliers, err := preferredSuppliers(q)
+	if err != nil {
+		return list, err
+	}
+
+	rows, err := q.Query(`
+		SELECT p.id, p.name, p.quantity, p.min_level, p.reorder_point, p.target_level, p.cost_price,
+			p.unit_id, u.abbreviation, u.dimension, u.factor
+		FROM products p
+		JOIN units u ON p.unit_id = u.id
+		ORDER BY p.name, p.id
+	`)
+	if err != nil {
+		return list, err
+	}
+	defer rows.Close()
+
+	groups := make(map[int]*models.ShoppingGroup)
+	var unassigned *models.ShoppingGroup
+	for rows.Next() {
+		var l models.ShoppingLine
+		var quantity, minLevel, targetLevel int
+		var costPrice, factor float64
+		var dimension string
+		err := rows.Scan(&l.ProductID, &l.ProductName, &quantity, &minLevel, &l.ReorderPoint, &targetLevel, &costPrice,
+			&l.UnitID, &l.UnitAbbreviation, &dimension, &factor)
+		if err != nil {
+			return list, err
+		}
+
+		supplier, hasSupplier := suppliers[l.ProductID]
+		leadTime := 0
+		if hasSupplier {
+			leadTime = supplier.leadTimeDays
+		}
+
+		l.BaseUnit = models.BaseUnits[dimension]
+		l.Available = quantity - reserved[l.ProductID] - blocked[l.ProductID]
+		l.OnOrder = onOrder[l.ProductID]
+		l.AverageDailyUsage = math.Round(usage[l.ProductID]*100) / 100
+		demand := int(math.Ceil(usage[l.ProductID]*float64(leadTime+coverageDays) - 1e-9))
+		l.RequiredLevel = max(targetLevel, l.ReorderPoint, minLevel+demand)
+		l.SuggestedQuantity = l.RequiredLevel - l.Available - l.OnOrder
+		if l.SuggestedQuantity <= 0 {
+			continue
+		}
+
+		l.Price = costPrice
+		if hasSupplier {
+			l.UnitID, l.UnitAbbreviation, factor, l.Price = supplier.unitID, supplier.unit, supplier.factor, supplier.price
+		}
+		if factor <= 0 {
+			factor = 1
+		}
+		l.OrderQuantity = math.Ceil(float64(l.SuggestedQuantity)/factor - 1e-9)
+		l.Amount = utils.RoundMoney(l.OrderQuantity * l.Price)
+
+		var group *models.ShoppingGroup
+		switch {
+		case hasSupplier && groups[supplier.supplierID] != nil:
+			group = groups[supplier.supplierID]
+		case hasSupplier:
+			supplierID := supplier.supplierID
+			group = &models.ShoppingGroup{SupplierID: &supplierID, SupplierName: supplier.name, LeadTimeDays: leadTime}
+			groups[supplierID] = group
+		default:
+			if unassigned == nil {
+				unassigned = &models.ShoppingGroup{}
+			}
+			group = unassigned
+		}
+		group.Lines = append(group.Lines, l)
+		group.Total += l.Amount
+	}
+	if err := rows.Err(); err != nil {
+		return list, err
+	}
+
+	for _, group := range groups {
+		list.Groups = append(list.Groups, *group)
+	}
+	sort.Slice(list.Groups, func(i, j int) bool {
+		return list.Groups[i].SupplierName < list.Groups[j].SupplierName
+	})
+	if unassigned != nil {
+		list.Groups = append(list.Groups, *unassigned)
+	}
+	for i := range list.Groups {
+		list.Groups[i].Total = utils.RoundMoney(list.Groups[i].Total)
+		list.Total += list.Groups[i].Total
+	}
+	list.Total = utils.RoundMoney(list.Total)
+	return list, nil
+}
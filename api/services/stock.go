@@ -0,0 +1,132 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+
+	"wuwunchik.github.io/api/models"
+)
+
+var (
+	ErrProductNotFound           = errors.New("product not found")
+	ErrUnitNotFound              = errors.New("unit not found")
+	ErrIncompatibleUnit          = errors.New("unit is not compatible with product unit")
+	ErrInsufficientStock         = errors.New("insufficient stock")
+	ErrInvalidQuantity           = errors.New("quantity must be positive")
+	ErrLotNotFound               = errors.New("lot not found")
+	ErrLotBlocked                = errors.New("lot is blocked")
+	ErrInsufficientLotStock      = errors.New("insufficient stock in lot")
+	ErrLocationNotFound          = errors.New("location not found")
+	ErrInsufficientLocationStock = errors.New("insufficient stock in location")
+)
+
+// Querier — общий интерфейс *sql.DB и *sql.Tx
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// ConvertToBase переводит количество в указанной единице в базовые единицы продукта.
+// Единица должна иметь ту же размерность, что и единица продукта, либо для продукта
+// должен быть задан её пересчёт (product_unit_conversions).
+func ConvertToBase(q Querier, productID, unitID int, quantity float64) (float64, error) {
+	var productDimension string
+	err := q.QueryRow(`
+		SELECT u.dimension FROM products p JOIN units u ON p.unit_id = u.id WHERE p.id = ?
+	`, productID).Scan(&productDimension)
+	if err == sql.ErrNoRows {
+		return 0, ErrProductNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var dimension string
+	var factor float64
+	err = q.QueryRow("SELECT dimension, factor FROM units WHERE id = ?", unitID).Scan(&dimension, &factor)
+	if err == sql.ErrNoRows {
+		return 0, ErrUnitNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if dimension != productDimension {
+		// Единица другой размерности допустима, если для продукта задан её пересчёт
+		err := q.QueryRow(`
+			SELECT factor FROM product_unit_conversions WHERE product_id = ? AND unit_id = ?
+		`, productID, unitID).Scan(&factor)
+		if err == sql.ErrNoRows {
+			return 0, ErrIncompatibleUnit
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return quantity * factor, nil
+}
+
+// PostMovement проводит движение товара: изменяет остаток продукта (а также партии
+// и места хранения, если они указаны) и записывает движение в журнал.
+// Остатки не могут стать отрицательными.
+func PostMovement(tx *sql.Tx, m *models.StockMovement) error {
+	if m.LocationID == 0 && m.Quantity < 0 {
+		// Расход без места хранения возможен только из нераспределённого остатка
+		unassigned, err := UnassignedQuantity(tx, m.ProductID)
+		if err != nil {
+			return err
+		}
+		if unassigned+m.Quantity < 0 {
+			return ErrInsufficientStock
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE products SET quantity = quantity + ? WHERE id = ? AND quantity + ? >= 0
+	`, m.Quantity, m.ProductID, m.Quantity)
+	if err != nil {
+		return err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		var exists int
+		err := tx.QueryRow("SELECT COUNT(*) FROM products WHERE id = ?", m.ProductID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists == 0 {
+			return ErrProductNotFound
+		}
+		return ErrInsufficientStock
+	}
+
+	if m.LotID != 0 {
+		result, err := tx.Exec(`
+			UPDATE lots SET quantity = quantity + ? WHERE id = ? AND product_id = ? AND quantity + ? >= 0
+		`, m.Quantity, m.LotID, m.ProductID, m.Quantity)
+		if err != nil {
+			return err
+		}
+		if affected, _ := result.RowsAffected(); affected == 0 {
+			return ErrInsufficientLotStock
+		}
+	}
+
+	if m.LocationID != 0 {
+		if err := changeLocationStock(tx, m.LocationID, m.ProductID, m.Quantity); err != nil {
+			return err
+		}
+	}
+
+	result, err = tx.Exec(`
+		INSERT INTO stock_movements (product_id, movement_type, quantity, unit_cost, lot_id, location_id, reference_type, reference_id, note, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, m.ProductID, m.MovementType, m.Quantity, m.UnitCost, m.LotID, m.LocationID, m.ReferenceType, m.ReferenceID, m.Note, m.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	id, _ := result.LastInsertId()
+	m.ID = int(id)
+	return tx.QueryRow("SELECT created_at FROM stock_movements WHERE id = ?", m.ID).Scan(&m.CreatedAt)
+}
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+var (
+	ErrStorageUnitNotFound      = errors.New("storage unit not found")
+	ErrStorageUnitInactive      = errors.New("storage unit is inactive")
+	ErrInvalidStorageType       = errors.New("unit_type must be fridge, freezer, cold_room or dry_store")
+	ErrInvalidTemperatureRange  = errors.New("min_temp must not be greater than max_temp")
+	ErrCorrectiveActionNotFound = errors.New("corrective action not found")
+	ErrCorrectiveActionDone     = errors.New("corrective action is already done")
+)
+
+// IsValidStorageType проверяет тип оборудования для хранения
+func IsValidStorageType(unitType string) bool {
+	switch unitType {
+	case models.StorageFridge, models.StorageFreezer, models.StorageColdRoom, models.StorageDry:
+		return true
+	}
+	return false
+}
+
+// LoadStorageUnit загружает место хранения с последним показанием и признаком открытого нарушения
+func LoadStorageUnit(q Querier, id int) (models.StorageUnit, error) {
+	var u models.StorageUnit
+	err := q.QueryRow(`
+		SELECT id, name, unit_type, location_id, min_temp, max_temp, active FROM storage_units WHERE id = ?
+	`, id).Scan(&u.ID, &u.Name, &u.UnitType, &u.LocationID, &u.MinTemp, &u.MaxTemp, &u.Active)
+	if err == sql.ErrNoRows {
+		return u, ErrStorageUnitNotFound
+	}
+	if err != nil {
+		return u, err
+	}
+
+	var reading models.TemperatureReading
+	err = q.QueryRow(`
+		SELECT id, storage_unit_id, temperature, humidity, in_range, note, recorded_by, recorded_at
+		FROM temperature_readings WHERE storage_unit_id = ?
+		ORDER BY recorded_at DESC, id DESC LIMIT 1
+	`, id).Scan(&reading.ID, &reading.StorageUnitID, &reading.Temperature, &reading.Humidity, &reading.InRange,
+		&reading.Note, &reading.RecordedBy, &reading.RecordedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return u, err
+	}
+	if err == nil {
+		u.LastReading = &reading
+	}
+
+	var open int
+	err = q.QueryRow(`
+		SELECT COUNT(*) FROM temperature_alerts WHERE storage_unit_id = ? AND resolved_at IS NULL
+	`, id).Scan(&open)
+	u.OpenAlert = open > 0
+	return u, err
+}
+
+// RecordTemperature записывает показание в журнал. Первое показание вне допустимого
+// диапазона открывает нарушение с ожидающим корректирующим действием; пока нарушение
+// открыто, новые показания вне диапазона его не дублируют. Показание в диапазоне
+// закрывает открытое нарушение. Показание задним числом (старше последнего в журнале)
+// только записывается и нарушения не открывает и не закрывает.
+// Возвращает нарушение, открытое этим показанием.
+func RecordTemperature(tx *sql.Tx, reading *models.TemperatureReading, recordedAt time.Time) (*models.TemperatureAlert, error) {
+	unit, err := LoadStorageUnit(tx, reading.StorageUnitID)
+	if err != nil {
+		return nil, err
+	}
+	if !unit.Active {
+		return nil, ErrStorageUnitInactive
+	}
+
+	reading.StorageUnitName = unit.Name
+	reading.InRange = reading.Temperature >= unit.MinTemp && reading.Temperature <= unit.MaxTemp
+	reading.RecordedAt = recordedAt.UTC().Format(time.RFC3339)
+	result, err := tx.Exec(`
+		INSERT INTO temperature_readings (storage_unit_id, temperature, humidity, in_range, note, recorded_by, recorded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, reading.StorageUnitID, reading.Temperature, reading.Humidity, reading.InRange, reading.Note,
+		reading.RecordedBy, utils.FormatDBTime(recordedAt))
+	if err != nil {
+		return nil, err
+	}
+	id, _ := result.LastInsertId()
+	reading.ID = int(id)
+
+	var newer int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM temperature_readings WHERE storage_unit_id = ? AND recorded_at > ?
+	`, unit.ID, utils.FormatDBTime(recordedAt)).Scan(&newer)
+	if err != nil {
+		return nil, err
+	}
+	if newer > 0 {
+		return nil, nil
+	}
+
+	if reading.InRange {
+		_, err = tx.Exec(`
+			UPDATE temperature_alerts SET resolved_at = ? WHERE storage_unit_id = ? AND resolved_at IS NULL
+		`, utils.FormatDBTime(recordedAt), unit.ID)
+		return nil, err
+	}
+	if unit.OpenAlert {
+		return nil, nil
+	}
+
+	result, err = tx.Exec(`
+		INSERT INTO temperature_alerts (storage_unit_id, reading_id, temperature, min_temp, max_temp, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, unit.ID, reading.ID, reading.Temperature, unit.MinTemp, unit.MaxTemp, utils.FormatDBTime(recordedAt))
+	if err != nil {
+		return nil, err
+	}
+	alertID, _ := result.LastInsertId()
+	_, err = tx.Exec("INSERT INTO corrective_actions (alert_id, status) VALUES (?, ?)", alertID, models.CorrectiveActionPending)
+	if err != nil {
+		return nil, err
+	}
+
+	alert, err := LoadTemperatureAlert(tx, int(alertID))
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// LoadTemperatureAlert загружает нарушение температурного режима с корректирующими действиями
+func LoadTemperatureAlert(q Querier, id int) (models.TemperatureAlert, error) {
+	var a models.TemperatureAlert
+	err := q.QueryRow(`
+		SELECT a.id, a.storage_unit_id, s.name, a.reading_id, a.temperature, a.min_temp, a.max_temp,
+			a.created_at, a.resolved_at
+		FROM temperature_alerts a
+		JOIN storage_units s ON a.storage_unit_id = s.id
+		WHERE a.id = ?
+	`, id).Scan(&a.ID, &a.StorageUnitID, &a.StorageUnitName, &a.ReadingID, &a.Temperature, &a.MinTemp, &a.MaxTemp,
+		&a.CreatedAt, &a.ResolvedAt)
+	if err != nil {
+		return a, err
+	}
+
+	rows, err := q.Query(`
+		SELECT id, alert_id, status, action, taken_by, taken_at, created_at
+		FROM corrective_actions WHERE alert_id = ? ORDER BY id
+	`, id)
+	if err != nil {
+		return a, err
+	}
+	defer rows.Close()
+	a.Actions = []models.CorrectiveAction{}
+	for rows.Next() {
+		var c models.CorrectiveAction
+		if err := rows.Scan(&c.ID, &c.AlertID, &c.Status, &c.Action, &c.TakenBy, &c.TakenAt, &c.CreatedAt); err != nil {
+			return a, err
+		}
+		a.Actions = append(a.Actions, c)
+	}
+	return a, rows.Err()
+}
+
+// CompleteCorrectiveAction отмечает корректирующее действие выполненным с описанием
+// того, что было сделано; возвращает нарушение, к которому относится действие
+func CompleteCorrectiveAction(tx *sql.Tx, id int, action, username string) (models.TemperatureAlert, error) {
+	var alertID int
+	var status string
+	err := tx.QueryRow("SELECT alert_id, status FROM corrective_actions WHERE id = ?", id).Scan(&alertID, &status)
+	if err == sql.ErrNoRows {
+		return models.TemperatureAlert{}, ErrCorrectiveActionNotFound
+	}
+	if err != nil {
+		return models.TemperatureAlert{}, err
+	}
+	if status != models.CorrectiveActionPending {
+		return models.TemperatureAlert{}, ErrCorrectiveActionDone
+	}
+
+	_, err = tx.Exec(`
+		UPDATE corrective_actions SET status = ?, action = ?, taken_by = ?, taken_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, models.CorrectiveActionDone, action, username, id)
+	if err != nil {
+		return models.TemperatureAlert{}, err
+	}
+	return LoadTemperatureAlert(tx, alertID)
+}
+
+// BuildComplianceReport строит отчёт о соблюдении температурного режима за период:
+// сводку показаний по каждому активному месту хранения и нарушения с корректирующими действиями
+func BuildComplianceReport(q Querier, from, to time.Time) (models.ComplianceReport, error) {
+	report := models.ComplianceReport{
+		From:      from.UTC().Format(time.RFC3339),
+		To:        to.UTC().Format(time.RFC3339),
+		Units:     []models.StorageCompliance{},
+		Incidents: []models.TemperatureAlert{},
+	}
+	start, end := utils.FormatDBTime(from), utils.FormatDBTime(to)
+
+	rows, err := q.Query(`
+		SELECT s.id, s.name, s.unit_type, s.min_temp, s.max_temp,
+			COUNT(r.id), COALESCE(SUM(CASE WHEN r.in_range = 0 THEN 1 ELSE 0 END), 0),
+			MIN(r.temperature), MAX(r.temperature)
+		FROM storage_units s
+		LEFT JOIN temperature_readings r ON r.storage_unit_id = s.id AND r.recorded_at >= ? AND r.recorded_at <= ?
+		WHERE s.active = 1 OR r.id IS NOT NULL
+		GROUP BY s.id
+		ORDER BY s.name
+	`, start, end)
+	if err != nil {
+		return report, err
+	}
+	for rows.Next() {
+		var c models.StorageCompliance
+		if err := rows.Scan(&c.StorageUnitID, &c.Name, &c.UnitType, &c.MinTemp, &c.MaxTemp,
+			&c.Readings, &c.OutOfRange, &c.LowestRecorded, &c.HighestRecorded); err != nil {
+			rows.Close()
+			return report, err
+		}
+		if c.Readings > 0 {
+			c.CompliancePercent = math.Round(float64(c.Readings-c.OutOfRange)/float64(c.Readings)*1000) / 10
+		}
+		report.Units = append(report.Units, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	days := 0
+	for d := from.UTC().Truncate(24 * time.Hour); !d.After(to.UTC()); d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	for i := range report.Units {
+		c := &report.Units[i]
+		var recordedDays int
+		err := q.QueryRow(`
+			SELECT COUNT(DISTINCT DATE(recorded_at)) FROM temperature_readings
+			WHERE storage_unit_id = ? AND recorded_at >= ? AND recorded_at <= ?
+		`, c.StorageUnitID, start, end).Scan(&recordedDays)
+		if err != nil {
+			return report, err
+		}
+		c.DaysWithoutReadings = days - recordedDays
+
+		err = q.QueryRow(`
+			SELECT COUNT(DISTINCT a.id), COALESCE(SUM(CASE WHEN ca.status = ? THEN 1 ELSE 0 END), 0)
+			FROM temperature_alerts a
+			LEFT JOIN corrective_actions ca ON ca.alert_id = a.id
+			WHERE a.storage_unit_id = ? AND a.created_at >= ? AND a.created_at <= ?
+		`, models.CorrectiveActionPending, c.StorageUnitID, start, end).Scan(&c.Alerts, &c.PendingActions)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	rows, err = q.Query(`
+		SELECT id FROM temperature_alerts WHERE created_at >= ? AND created_at <= ? ORDER BY created_at, id
+	`, start, end)
+	if err != nil {
+		return report, err
+	}
+	var alertIDs []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return report, err
+		}
+		alertIDs = append(alertIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+	for _, id := range alertIDs {
+		alert, err := LoadTemperatureAlert(q, id)
+		if err != nil {
+			return report, err
+		}
+		report.Incidents = append(report.Incidents, alert)
+	}
+	return report, nil
+}
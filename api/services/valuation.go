@@ -0,0 +1,233 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// costLayer — слой FIFO: остаток одного поступления и его стоимость за базовую единицу
+type costLayer struct {
+	quantity int
+	cost     float64
+}
+
+// productValuation накапливает оценку остатка одного продукта при проходе по журналу
+type productValuation struct {
+	quantity    int
+	layers      []costLayer
+	averageCost float64
+	defaultCost float64 // текущая себестоимость за базовую единицу
+}
+
+// receive учитывает поступление. Движения без стоимости (корректировки, начальные
+// остатки) оцениваются по текущей средней, а при её отсутствии — по себестоимости продукта.
+func (v *productValuation) receive(quantity int, cost float64) {
+	if cost <= 0 {
+		cost = v.averageCost
+		if v.quantity <= 0 || cost <= 0 {
+			cost = v.defaultCost
+		}
+	}
+
+	if v.quantity < 0 {
+		v.quantity = 0
+	}
+	v.averageCost = (v.averageCost*float64(v.quantity) + cost*float64(quantity)) / float64(v.quantity+quantity)
+	v.quantity += quantity
+	v.layers = append(v.layers, costLayer{quantity: quantity, cost: cost})
+}
+
+// issue списывает количество из самых старых слоёв FIFO; средняя стоимость не меняется
+func (v *productValuation) issue(quantity int) {
+	v.quantity -= quantity
+	for quantity > 0 && len(v.layers) > 0 {
+		if v.layers[0].quantity > quantity {
+			v.layers[0].quantity -= quantity
+			return
+		}
+		quantity -= v.layers[0].quantity
+		v.layers = v.layers[1:]
+	}
+}
+
+func (v *productValuation) fifoValue() float64 {
+	var value float64
+	for _, layer := range v.layers {
+		value += float64(layer.quantity) * layer.cost
+	}
+	return value
+}
+
+// InventoryValuation оценивает остатки на момент asOf (включительно) по FIFO и по скользящей средней.
+// Остаток, существовавший до ведения журнала движений, считается поступившим первым
+// по текущей себестоимости продукта. Перемещения между местами хранения не влияют на оценку.
+func InventoryValuation(q Querier, asOf time.Time) (models.ValuationReport, error) {
+	report := models.ValuationReport{
+		AsOf:     asOf.UTC().Format(time.RFC3339),
+		Currency: models.DefaultCurrency,
+		Items:    []models.ValuationItem{},
+	}
+
+	rows, err := q.Query(`
+		SELECT p.id, p.name, p.quantity, u.dimension, u.factor, COALESCE(p.cost_price, 0),
+			p.quantity - COALESCE((
+				SELECT SUM(m.quantity) FROM stock_movements m
+				WHERE m.product_id = p.id AND m.movement_type != ?
+			), 0)
+		FROM products p
+		JOIN units u ON p.unit_id = u.id
+		ORDER BY p.name, p.id
+	`, models.MovementTransfer)
+	if err != nil {
+		return report, err
+	}
+
+	var order []int
+	names := make(map[int]string)
+	baseUnits := make(map[int]string)
+	valuations := make(map[int]*productValuation)
+	for rows.Next() {
+		var id, quantity, opening int
+		var name, dimension string
+		var factor, costPrice float64
+		if err := rows.Scan(&id, &name, &quantity, &dimension, &factor, &costPrice, &opening); err != nil {
+			rows.Close()
+			return report, err
+		}
+
+		v := &productValuation{}
+		if factor > 0 {
+			v.defaultCost = costPrice / factor
+		}
+		if opening > 0 {
+			v.receive(opening, 0)
+		}
+		order = append(order, id)
+		names[id] = name
+		baseUnits[id] = models.BaseUnits[dimension]
+		valuations[id] = v
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	rows, err = q.Query(`
+		SELECT product_id, quantity, unit_cost
+		FROM stock_movements
+		WHERE movement_type != ? AND created_at <= ?
+		ORDER BY created_at, id
+	`, models.MovementTransfer, utils.FormatDBTime(asOf))
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var productID, quantity int
+		var unitCost float64
+		if err := rows.Scan(&productID, &quantity, &unitCost); err != nil {
+			return report, err
+		}
+		v, ok := valuations[productID]
+		if !ok {
+			continue
+		}
+		if quantity > 0 {
+			v.receive(quantity, unitCost)
+		} else {
+			v.issue(-quantity)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	for _, id := range order {
+		v := valuations[id]
+		if v.quantity <= 0 {
+			continue
+		}
+		item := models.ValuationItem{
+			ProductID:    id,
+			ProductName:  names[id],
+			Quantity:     v.quantity,
+			BaseUnit:     baseUnits[id],
+			FIFOValue:    utils.RoundMoney(v.fifoValue()),
+			AverageCost:  v.averageCost,
+			AverageValue: utils.RoundMoney(v.averageCost * float64(v.quantity)),
+		}
+		report.Items = append(report.Items, item)
+		report.FIFOTotal += item.FIFOValue
+		report.AverageTotal += item.AverageValue
+	}
+	report.FIFOTotal = utils.RoundMoney(report.FIFOTotal)
+	report.AverageTotal = utils.RoundMoney(report.AverageTotal)
+
+	return report, nil
+}
+
+// MovingAverageCost возвращает текущую скользящую среднюю стоимость базовой единицы продукта,
+// рассчитанную по журналу так же, как в InventoryValuation; без поступлений со стоимостью —
+// себестоимость продукта
+func MovingAverageCost(q Querier, productID int) (float64, error) {
+	var factor, costPrice float64
+	var opening int
+	err := q.QueryRow(`
+		SELECT u.factor, COALESCE(p.cost_price, 0),
+			p.quantity - COALESCE((
+				SELECT SUM(m.quantity) FROM stock_movements m
+				WHERE m.product_id = p.id AND m.movement_type != ?
+			), 0)
+		FROM products p
+		JOIN units u ON p.unit_id = u.id
+		WHERE p.id = ?
+	`, models.MovementTransfer, productID).Scan(&factor, &costPrice, &opening)
+	if err == sql.ErrNoRows {
+		return 0, ErrProductNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	v := &productValuation{}
+	if factor > 0 {
+		v.defaultCost = costPrice / factor
+	}
+	if opening > 0 {
+		v.receive(opening, 0)
+	}
+
+	rows, err := q.Query(`
+		SELECT quantity, unit_cost FROM stock_movements
+		WHERE product_id = ? AND movement_type != ?
+		ORDER BY created_at, id
+	`, productID, models.MovementTransfer)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var quantity int
+		var unitCost float64
+		if err := rows.Scan(&quantity, &unitCost); err != nil {
+			return 0, err
+		}
+		if quantity > 0 {
+			v.receive(quantity, unitCost)
+		} else {
+			v.issue(-quantity)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if v.averageCost > 0 {
+		return v.averageCost, nil
+	}
+	return v.defaultCost, nil
+}
@@ -0,0 +1,318 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"wuwunchik.github.io/api/models"
+	"wuwunchik.github.io/api/utils"
+)
+
+// WriteOffReferenceType — тип документа в движениях, созданных списанием
+const WriteOffReferenceType = "write_off"
+
+// DefaultWriteOffApprovalThreshold — стоимость списания, выше которой нужен менеджер,
+// если WRITE_OFF_APPROVAL_THRESHOLD не задан
+const DefaultWriteOffApprovalThreshold = 1000.0
+
+var (
+	ErrWriteOffNotFound      = errors.New("write-off not found")
+	ErrInvalidWriteOffReason = errors.New("reason must be expired, damaged, preparation_loss or theft")
+	ErrWriteOffNotPending    = errors.New("write-off is not pending")
+)
+
+// WriteOffApprovalThreshold возвращает порог утверждения списаний из переменной
+// окружения WRITE_OFF_APPROVAL_THRESHOLD
+func WriteOffApprovalThreshold() float64 {
+	if value := os.Getenv("WRITE_OFF_APPROVAL_THRESHOLD"); value != "" {
+		if threshold, err := strconv.ParseFloat(value, 64); err == nil && threshold >= 0 {
+			return threshold
+		}
+	}
+	return DefaultWriteOffApprovalThreshold
+}
+
+// IsValidWriteOffReason проверяет причину списания
+func IsValidWriteOffReason(reason string) bool {
+	switch reason {
+	case models.WriteOffExpired, models.WriteOffDamaged, models.WriteOffPreparationLoss, models.WriteOffTheft:
+		return true
+	}
+	return false
+}
+
+// LoadWriteOff загружает акт списания
+func LoadWriteOff(q Querier, id int) (models.WriteOff, error) {
+	var wo models.WriteOff
+	var dimension string
+	var unitCost, cost float64
+	err := q.QueryRow(`
+		SELECT w.id, w.product_id, p.name, w.quantity, u.dimension, w.reason, w.unit_cost, w.cost,
+			w.location_id, w.lot_id, w.status, w.note, w.created_by, w.created_at, w.reviewed_by, w.reviewed_at
+		FROM write_offs w
+		JOIN products p ON w.product_id = p.id
+		JOIN units u ON p.unit_id = u.id
+		WHERE w.id = ?
+	`, id).Scan(&wo.ID, &wo.ProductID, &wo.ProductName, &wo.Quantity, &dimension, &wo.Reason, &unitCost, &cost,
+		&wo.LocationID, &wo.LotID, &wo.Status, &wo.Note, &wo.CreatedBy, &wo.CreatedAt, &wo.ReviewedBy, &wo.ReviewedAt)
+	if err == sql.ErrNoRows {
+		return wo, ErrWriteOffNotFound
+	}
+	if err != nil {
+		return wo, err
+	}
+	wo.BaseUnit = models.BaseUnits[dimension]
+	wo.UnitCost = &unitCost
+	wo.Cost = &cost
+	wo.Currency = models.DefaultCurrency
+	return wo, nil
+}
+
+// CreateWriteOff сохраняет акт списания по текущей себестоимости продукта. Если стоимость
+// не превышает порог или акт создаёт менеджер (approve), товар сразу списывается;
+// иначе акт ждёт утверждения.
+func CreateWriteOff(tx *sql.Tx, wo *models.WriteOff, approve bool) error {
+	if wo.Quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+	if !IsValidWriteOffReason(wo.Reason) {
+		return ErrInvalidWriteOffReason
+	}
+
+	var unitCost float64
+	err := tx.QueryRow(`
+		SELECT CASE WHEN u.factor > 0 THEN p.cost_price / u.factor ELSE 0 END
+		FROM products p JOIN units u ON p.unit_id = u.id WHERE p.id = ?
+	`, wo.ProductID).Scan(&unitCost)
+	if err == sql.ErrNoRows {
+		return ErrProductNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if wo.LotID != nil {
+		var exists int
+		err := tx.QueryRow("SELECT COUNT(*) FROM lots WHERE id = ? AND product_id = ?", *wo.LotID, wo.ProductID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists == 0 {
+			return ErrLotNotFound
+		}
+	}
+	cost := utils.RoundMoney(float64(wo.Quantity) * unitCost)
+
+	result, err := tx.Exec(`
+		INSERT INTO write_offs (product_id, quantity, reason, unit_cost, cost, location_id, lot_id, status, note, created_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, wo.ProductID, wo.Quantity, wo.Reason, unitCost, cost, wo.LocationID, wo.LotID,
+		models.WriteOffPending, wo.Note, wo.CreatedBy)
+	if err != nil {
+		return err
+	}
+	id, _ := result.LastInsertId()
+	wo.ID = int(id)
+
+	if approve || cost <= WriteOffApprovalThreshold() {
+		return ApproveWriteOff(tx, wo.ID, wo.CreatedBy)
+	}
+	return nil
+}
+
+// ApproveWriteOff проводит ожидающий акт списания движением расхода. Партия, указанная
+// в акте, списывается даже заблокированной (просроченный товар). Без партии просроченный
+// и испорченный товар сначала списывается из заблокированных и просроченных партий,
+// остальное — из свободного остатка по FEFO.
+func ApproveWriteOff(tx *sql.Tx, id int, reviewedBy string) error {
+	wo, err := LoadWriteOff(tx, id)
+	if err != nil {
+		return err
+	}
+	if wo.Status != models.WriteOffPending {
+		return ErrWriteOffNotPending
+	}
+
+	movement := models.StockMovement{
+		ProductID:     wo.ProductID,
+		MovementType:  models.MovementWriteOff,
+		UnitCost:      *wo.UnitCost,
+		ReferenceType: WriteOffReferenceType,
+		ReferenceID:   wo.ID,
+		Note:          wo.Note,
+		CreatedBy:     reviewedBy,
+	}
+	if wo.LocationID != nil {
+		movement.LocationID = *wo.LocationID
+	}
+
+	var lots []models.LotAllocation
+	switch {
+	case wo.LotID != nil:
+		lots = []models.LotAllocation{{LotID: *wo.LotID, Quantity: wo.Quantity}}
+	case wo.Reason == models.WriteOffExpired || wo.Reason == models.WriteOffDamaged:
+		lots, err = unusableLots(tx, wo.ProductID)
+		if err != nil {
+			return err
+		}
+	}
+
+	remaining := wo.Quantity
+	for _, l := range lots {
+		if remaining == 0 {
+			break
+		}
+		quantity := min(l.Quantity, remaining)
+		var unusable int
+		err := tx.QueryRow("SELECT COUNT(*) FROM lots WHERE id = ? AND "+unusableLot, l.LotID, time.Now().Format("2006-01-02")).
+			Scan(&unusable)
+		if err != nil {
+			return err
+		}
+		// Заблокированные партии не входят в свободный остаток, его проверять не нужно
+		if unusable == 0 {
+			if err := checkAvailable(tx, wo.ProductID, quantity); err != nil {
+				return err
+			}
+		}
+		m := movement
+		m.LotID = l.LotID
+		m.Quantity = -quantity
+		if _, err := PostIssue(tx, m); err != nil {
+			return err
+		}
+		remaining -= quantity
+	}
+
+	if remaining > 0 {
+		if err := checkAvailable(tx, wo.ProductID, remaining); err != nil {
+			return err
+		}
+		movement.Quantity = remaining
+		if _, err := IssueStock(tx, movement, nil); err != nil {
+			return err
+		}
+	}
+
+	return reviewWriteOff(tx, id, models.WriteOffApproved, reviewedBy)
+}
+
+// unusableLots возвращает заблокированные и просроченные партии продукта с остатком
+// в порядке истечения срока годности
+func unusableLots(q Querier, productID int) ([]models.LotAllocation, error) {
+	rows, err := q.Query(`
+		SELECT id, quantity FROM lots
+		WHERE product_id = ? AND quantity > 0 AND `+unusableLot+`
+		ORDER BY expiry_date IS NULL, expiry_date, received_at, id
+	`, productID, time.Now().Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lots []models.LotAllocation
+	for rows.Next() {
+		var l models.LotAllocation
+		if err := rows.Scan(&l.LotID, &l.Quantity); err != nil {
+			return nil, err
+		}
+		lots = append(lots, l)
+	}
+	return lots, rows.Err()
+}
+
+// checkAvailable проверяет, что свободного остатка продукта хватает на quantity
+func checkAvailable(q Querier, productID, quantity int) error {
+	available, err := AvailableQuantity(q, productID)
+	if err != nil {
+		return err
+	}
+	if available < quantity {
+		return ErrInsufficientAvailableStock
+	}
+	return nil
+}
+
+// RejectWriteOff отклоняет ожидающий акт списания без изменения остатков
+func RejectWriteOff(tx *sql.Tx, id int, reviewedBy string) error {
+	wo, err := LoadWriteOff(tx, id)
+	if err != nil {
+		return err
+	}
+	if wo.Status != models.WriteOffPending {
+		return ErrWriteOffNotPending
+	}
+	return reviewWriteOff(tx, id, models.WriteOffRejected, reviewedBy)
+}
+
+func reviewWriteOff(tx *sql.Tx, id int, status, reviewedBy string) error {
+	_, err := tx.Exec(`
+		UPDATE write_offs SET status = ?, reviewed_by = ?, reviewed_at = CURRENT_TIMESTAMP WHERE id = ?
+	`, status, reviewedBy, id)
+	return err
+}
+
+// BuildWasteReport суммирует проведённые списания за период [from, to] по продуктам и причинам
+func BuildWasteReport(q Querier, from, to time.Time) (models.WasteReport, error) {
+	report := models.WasteReport{
+		From:      from.UTC().Format(time.RFC3339),
+		To:        to.UTC().Format(time.RFC3339),
+		Currency:  models.DefaultCurrency,
+		ByProduct: []models.WasteByProduct{},
+		ByReason:  []models.WasteByReason{},
+	}
+	args := []interface{}{models.WriteOffApproved, utils.FormatDBTime(from), utils.FormatDBTime(to)}
+
+	rows, err := q.Query(`
+		SELECT w.product_id, p.name, u.dimension, SUM(w.quantity), SUM(w.cost)
+		FROM write_offs w
+		JOIN products p ON w.product_id = p.id
+		JOIN units u ON p.unit_id = u.id
+		WHERE w.status = ? AND w.reviewed_at >= ? AND w.reviewed_at <= ?
+		GROUP BY w.product_id
+		ORDER BY SUM(w.cost) DESC, p.name
+	`, args...)
+	if err != nil {
+		return report, err
+	}
+	for rows.Next() {
+		var item models.WasteByProduct
+		var dimension string
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &dimension, &item.Quantity, &item.Cost); err != nil {
+			rows.Close()
+			return report, err
+		}
+		item.BaseUnit = models.BaseUnits[dimension]
+		item.Cost = utils.RoundMoney(item.Cost)
+		report.ByProduct = append(report.ByProduct, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return report, err
+	}
+
+	rows, err = q.Query(`
+		SELECT reason, COUNT(*), SUM(cost)
+		FROM write_offs
+		WHERE status = ? AND reviewed_at >= ? AND reviewed_at <= ?
+		GROUP BY reason
+		ORDER BY SUM(cost) DESC, reason
+	`, args...)
+	if err != nil {
+		return report, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var item models.WasteByReason
+		if err := rows.Scan(&item.Reason, &item.Count, &item.Cost); err != nil {
+			return report, err
+		}
+		item.Cost = utils.RoundMoney(item.Cost)
+		report.ByReason = append(report.ByReason, item)
+		report.Total += item.Cost
+	}
+	report.Total = utils.RoundMoney(report.Total)
+	return report, rows.Err()
+}
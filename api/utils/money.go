@@ -0,0 +1,8 @@
+package utils
+
+import "math"
+
+// RoundMoney округляет денежную сумму до копеек
+func RoundMoney(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"errors"
+	"time"
+)
+
+// DBTimeLayout — формат времени, в котором SQLite хранит CURRENT_TIMESTAMP (UTC)
+const DBTimeLayout = "2006-01-02 15:04:05"
+
+// ParseDateTime разбирает дату (YYYY-MM-DD) или дату со временем (RFC 3339 либо
+// «YYYY-MM-DD HH:MM:SS» в UTC)
+func ParseDateTime(value string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, DBTimeLayout, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, errors.New("invalid date (expected YYYY-MM-DD or RFC 3339)")
+}
+
+// FormatDBTime переводит время в формат, сравнимый с CURRENT_TIMESTAMP в SQLite
+func FormatDBTime(t time.Time) string {
+	return t.UTC().Format(DBTimeLayout)
+}
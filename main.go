@@ -3,11 +3,13 @@ package main
 import (
 	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"wuwunchik.github.io/api/database"
 	"wuwunchik.github.io/api/routes"
+	"wuwunchik.github.io/api/services"
 )
 
 func main() {
@@ -15,6 +17,17 @@ func main() {
 	router := mux.NewRouter()
 	routes.RegisterRoutes(router)
 
+	// Фоновая проверка остатков и оповещения о пересечении порогов
+	services.StartStockAlertChecker(time.Minute)
+	// Фоновая блокировка просроченных партий
+	services.StartLotExpiryChecker(time.Hour)
+	// Фоновое применение запланированных цен
+	services.StartPriceScheduler(time.Minute)
+	// Фоновое снятие истёкших резервов
+	services.StartReservationExpiryChecker(time.Minute)
+	// Фоновый пересчёт прогнозов расхода
+	services.StartForecastRecalculation(time.Hour)
+
 	// Настройка CORS
 	headersOk := handlers.AllowedHeaders([]string{"X-Requested-With", "Content-Type", "Authorization"})
 	originsOk := handlers.AllowedOrigins([]string{"*"})